@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"pencatatan/internal/config"
+	"pencatatan/internal/database"
+	"pencatatan/internal/metrics"
+	"pencatatan/internal/repository"
+	"pencatatan/internal/service"
+	"syscall"
+)
+
+const importUsage = "usage: main import [-dry-run] FILE.csv"
+
+// runImport loads sales from a CSV file with the same rules as
+// POST /api/sales/import and prints the report.
+func runImport(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "validate the file without saving anything")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New(importUsage)
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	db, err := database.New(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	saleService := service.NewSaleService(
+		repository.NewSaleRepository(db.DB(), cfg.DBQueryTimeout),
+		repository.NewProductRepository(db.DB(), cfg.DBQueryTimeout),
+		repository.NewCustomerRepository(db.DB(), cfg.DBQueryTimeout),
+		repository.NewDebtRepository(db.DB(), cfg.DBQueryTimeout),
+		repository.NewTxManager(db.DB()),
+		// Nothing scrapes the metrics of a one-off import.
+		metrics.New(db.DB()),
+		cfg.BusinessLocation,
+	)
+
+	// Ctrl+C stops the import between queries instead of killing it midway.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	report, err := saleService.ImportSales(ctx, file, *dryRun, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, rowErr := range report.Errors {
+		if rowErr.Column != "" {
+			fmt.Printf("line %d, %s: %s\n", rowErr.Line, rowErr.Column, rowErr.Error)
+		} else {
+			fmt.Printf("line %d: %s\n", rowErr.Line, rowErr.Error)
+		}
+	}
+	fmt.Printf("rows: %d, sales: %d, valid: %d, imported: %d, failed: %d, dry run: %t\n",
+		report.Rows, report.Sales, report.Valid, report.Imported, report.Failed, report.DryRun)
+
+	if report.Failed > 0 {
+		return fmt.Errorf("%d sales were not imported", report.Failed)
+	}
+
+	return nil
+}
@@ -4,33 +4,40 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
 	"os/signal"
 	"pencatatan/internal/app"
 	"pencatatan/internal/config"
 	"pencatatan/internal/database"
+	"pencatatan/internal/logging"
 	"pencatatan/internal/server"
 	"syscall"
 	"time"
 )
 
-func graceFullyShutdown(srv *http.Server, done chan bool) {
+// graceFullyShutdown stops the server on SIGINT or SIGTERM. Requests still
+// running when the grace period ends are cancelled through cancelRequests, which
+// also cancels their database queries.
+func graceFullyShutdown(srv *http.Server, cancelRequests context.CancelFunc, done chan bool) {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
 	<-ctx.Done()
 
-	log.Println("Shutting down gracefully, press Ctrl+C again to force")
+	slog.Info("shutting down gracefully, press Ctrl+C again to force")
 	stop()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Printf("Server forced to shutdown with error: %v", err)
+		slog.Error("server forced to shut down", "error", err)
 	}
+	cancelRequests()
 
-	log.Println("Server exiting")
+	slog.Info("server exiting")
 
 	done <- true
 }
@@ -38,20 +45,59 @@ func graceFullyShutdown(srv *http.Server, done chan bool) {
 func main() {
 	cfg, err := config.LoadConfig()
 	if err != nil {
-		log.Fatal("cannot load config:", err)
+		fatal("cannot load config", err)
+	}
+
+	slog.SetDefault(logging.New(os.Stdout, cfg.LogFormat, cfg.LogLevel))
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(cfg, os.Args[2:]); err != nil {
+			fatal("migrate", err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		if err := runImport(cfg, os.Args[2:]); err != nil {
+			fatal("import", err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "user" {
+		if err := runUser(cfg, os.Args[2:]); err != nil {
+			fatal("user", err)
+		}
+		return
+	}
+
+	if cfg.AutoMigrate {
+		if err := database.RunMigrations(cfg); err != nil {
+			fatal("cannot migrate database", err)
+		}
 	}
 
 	db, err := database.New(cfg)
 	if err != nil {
-		log.Fatal("cannot initialize database:", err)
+		fatal("cannot initialize database", err)
 	}
 
-	container := app.BuildContainer(db)
+	container := app.BuildContainer(cfg, db)
+
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	for _, job := range container.Jobs {
+		go job(jobsCtx)
+	}
+
+	requestsCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
 
 	srv := server.NewServer(cfg, container)
+	srv.BaseContext = func(net.Listener) context.Context { return requestsCtx }
 	done := make(chan bool, 1)
 
-	go graceFullyShutdown(srv, done)
+	go graceFullyShutdown(srv, cancelRequests, done)
 
 	err = srv.ListenAndServe()
 	if err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -59,5 +105,11 @@ func main() {
 	}
 
 	<-done
-	log.Println("Gracefully shutdown complete.")
+	slog.Info("graceful shutdown complete")
+}
+
+// fatal logs err and exits.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"pencatatan/internal/config"
+	"pencatatan/internal/database"
+	"strconv"
+)
+
+const migrateUsage = "usage: main migrate up [N] | down [N] | status | force VERSION"
+
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	mg, err := database.NewMigrator(cfg)
+	if err != nil {
+		return err
+	}
+	defer mg.Close()
+
+	switch args[0] {
+	case "up":
+		steps, err := optionalInt(args[1:], 0)
+		if err != nil {
+			return err
+		}
+		if err := mg.Up(steps); err != nil {
+			return err
+		}
+	case "down":
+		steps, err := optionalInt(args[1:], 1)
+		if err != nil {
+			return err
+		}
+		if err := mg.Down(steps); err != nil {
+			return err
+		}
+	case "force":
+		if len(args) < 2 {
+			return errors.New(migrateUsage)
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		if err := mg.Force(version); err != nil {
+			return err
+		}
+	case "status":
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	version, dirty, err := mg.Status()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("version: %d, dirty: %t\n", version, dirty)
+
+	return nil
+}
+
+func optionalInt(args []string, defaultValue int) (int, error) {
+	if len(args) == 0 {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid step count %q", args[0])
+	}
+	return n, nil
+}
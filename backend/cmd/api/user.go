@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"pencatatan/internal/config"
+	"pencatatan/internal/database"
+	"pencatatan/internal/models"
+	"pencatatan/internal/repository"
+	"pencatatan/internal/service"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+const userUsage = "usage: main user create [-name NAME] [-role owner|cashier|viewer] USERNAME"
+
+// runUser manages logins. The password is read from the terminal without
+// echo, or from the first line of stdin when it is piped in.
+func runUser(cfg *config.Config, args []string) error {
+	if len(args) == 0 || args[0] != "create" {
+		return errors.New(userUsage)
+	}
+
+	flags := flag.NewFlagSet("user create", flag.ContinueOnError)
+	name := flags.String("name", "", "display name of the user")
+	role := flags.String("role", string(models.RoleCashier), "owner, cashier or viewer")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New(userUsage)
+	}
+
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+
+	db, err := database.New(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	authService := service.NewAuthService(
+		repository.NewUserRepository(db.DB(), cfg.DBQueryTimeout),
+		repository.NewRefreshTokenRepository(db.DB(), cfg.DBQueryTimeout),
+		cfg.JWTSecret,
+		cfg.AccessTokenTTL,
+		cfg.RefreshTokenTTL,
+	)
+
+	user, err := authService.CreateUser(context.Background(), &models.CreateUserRequest{
+		Username: flags.Arg(0),
+		Name:     *name,
+		Role:     models.Role(*role),
+		Password: password,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("created %s %s (%s)\n", user.Role, user.Username, user.ID)
+	return nil
+}
+
+func readPassword() (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", errors.New("no password on stdin")
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	fmt.Print("Password: ")
+	password, err := term.ReadPassword(fd)
+	fmt.Println()
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Print("Repeat password: ")
+	repeated, err := term.ReadPassword(fd)
+	fmt.Println()
+	if err != nil {
+		return "", err
+	}
+
+	if string(password) != string(repeated) {
+		return "", errors.New("passwords do not match")
+	}
+
+	return string(password), nil
+}
@@ -1,26 +1,84 @@
 package app
 
 import (
+	"context"
+	"pencatatan/internal/config"
 	"pencatatan/internal/database"
 	"pencatatan/internal/handler"
+	"pencatatan/internal/metrics"
 	"pencatatan/internal/repository"
 	"pencatatan/internal/service"
 )
 
 type Container struct {
-	HealthHandler *handler.HealthHandler
-	SaleHandler   *handler.SaleHandler
+	HealthHandler      *handler.HealthHandler
+	SaleHandler        *handler.SaleHandler
+	ProductHandler     *handler.ProductHandler
+	CustomerHandler    *handler.CustomerHandler
+	DebtHandler        *handler.DebtHandler
+	ReportHandler      *handler.ReportHandler
+	AuthHandler        *handler.AuthHandler
+	IdempotencyHandler *handler.IdempotencyHandler
+	MetricsHandler     *handler.MetricsHandler
+
+	// Jobs run in the background for as long as the server is up. Each one
+	// returns when its context is cancelled.
+	Jobs []func(ctx context.Context)
 }
 
-func BuildContainer(db database.Service) *Container {
+func BuildContainer(cfg *config.Config, db database.Service) *Container {
 	healthHandler := handler.NewHealthHandler(db)
 
-	saleRepo := repository.NewSaleRepository(db.DB())
-	saleService := service.NewSaleService(saleRepo)
+	appMetrics := metrics.New(db.DB())
+	metricsHandler := handler.NewMetricsHandler(appMetrics, cfg.MetricsToken)
+
+	txManager := repository.NewTxManager(db.DB())
+
+	productRepo := repository.NewProductRepository(db.DB(), cfg.DBQueryTimeout)
+	productService := service.NewProductService(productRepo)
+	productHandler := handler.NewProductHandler(productService)
+
+	customerRepo := repository.NewCustomerRepository(db.DB(), cfg.DBQueryTimeout)
+	customerService := service.NewCustomerService(customerRepo)
+	customerHandler := handler.NewCustomerHandler(customerService)
+
+	debtRepo := repository.NewDebtRepository(db.DB(), cfg.DBQueryTimeout)
+	debtService := service.NewDebtService(debtRepo, customerRepo)
+	debtHandler := handler.NewDebtHandler(debtService)
+
+	saleRepo := repository.NewSaleRepository(db.DB(), cfg.DBQueryTimeout)
+	saleService := service.NewSaleService(saleRepo, productRepo, customerRepo, debtRepo, txManager, appMetrics, cfg.BusinessLocation)
 	saleHandler := handler.NewSaleHandler(saleService)
 
+	reportRepo := repository.NewReportRepository(db.DB(), cfg.DBQueryTimeout)
+	reportService := service.NewReportService(reportRepo, cfg.BusinessLocation)
+	reportHandler := handler.NewReportHandler(reportService)
+
+	userRepo := repository.NewUserRepository(db.DB(), cfg.DBQueryTimeout)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(db.DB(), cfg.DBQueryTimeout)
+	authService := service.NewAuthService(userRepo, refreshTokenRepo, cfg.JWTSecret, cfg.AccessTokenTTL, cfg.RefreshTokenTTL)
+	authHandler := handler.NewAuthHandler(authService)
+
+	idempotencyRepo := repository.NewIdempotencyRepository(db.DB(), cfg.DBQueryTimeout)
+	// Running requests renew their claim, so a claim left to expire for a few
+	// query timeouts belongs to a request that has died.
+	idempotencyLease := 4 * cfg.DBQueryTimeout
+	idempotencyService := service.NewIdempotencyService(idempotencyRepo, cfg.IdempotencyKeyTTL, idempotencyLease)
+	idempotencyHandler := handler.NewIdempotencyHandler(idempotencyService)
+
 	return &Container{
-		SaleHandler:   saleHandler,
-		HealthHandler: healthHandler,
+		SaleHandler:        saleHandler,
+		ProductHandler:     productHandler,
+		CustomerHandler:    customerHandler,
+		DebtHandler:        debtHandler,
+		ReportHandler:      reportHandler,
+		HealthHandler:      healthHandler,
+		AuthHandler:        authHandler,
+		IdempotencyHandler: idempotencyHandler,
+		MetricsHandler:     metricsHandler,
+		Jobs: []func(ctx context.Context){
+			purgeTrashJob(saleService, cfg.TrashRetention, cfg.PurgeInterval),
+			purgeIdempotencyKeysJob(idempotencyService, cfg.PurgeInterval),
+		},
 	}
 }
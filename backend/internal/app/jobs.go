@@ -0,0 +1,54 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"pencatatan/internal/service"
+	"time"
+)
+
+// purgeTrashJob permanently removes sales that have been in the trash longer
+// than retention. It runs once at start and then every interval. Running it on
+// several instances at once is harmless.
+func purgeTrashJob(sales service.SaleService, retention, interval time.Duration) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			purged, err := sales.PurgeTrash(ctx, retention)
+			if err != nil {
+				slog.ErrorContext(ctx, "purge trash", "error", err)
+			} else if purged > 0 {
+				slog.InfoContext(ctx, "purged sales from the trash", "count", purged, "retention", retention)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}
+}
+
+// purgeIdempotencyKeysJob removes idempotency keys past their TTL every
+// interval.
+func purgeIdempotencyKeysJob(keys service.IdempotencyService, interval time.Duration) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := keys.PurgeExpired(ctx); err != nil {
+				slog.ErrorContext(ctx, "purge idempotency keys", "error", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}
+}
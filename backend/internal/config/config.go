@@ -1,30 +1,155 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"log/slog"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	DBHost     string
-	DBPort     string
-	DBUser     string
-	DBPassword string
-	DBName     string
+	// DatabaseURL is the Postgres connection URL or keyword/value DSN, SSL
+	// settings included. It is DATABASE_URL when set and is otherwise built
+	// from DB_HOST, DB_PORT, DB_USERNAME, DB_PASSWORD and DB_DATABASE.
+	DatabaseURL string
+
+	// Connection pool limits.
+	DBMaxOpenConns    int
+	DBMaxIdleConns    int
+	DBConnMaxLifetime time.Duration
+	DBConnMaxIdleTime time.Duration
+
 	ServerPort string
+
+	// LogFormat is "text" or "json". Records below LogLevel are dropped.
+	LogFormat string
+	LogLevel  slog.Level
+
+	// DBQueryTimeout bounds each repository call. Requests are also cancelled
+	// when the client disconnects or the server shuts down.
+	DBQueryTimeout time.Duration
+
+	// AutoMigrate applies pending migrations when the API starts.
+	AutoMigrate bool
+
+	// BusinessLocation is the shop's time zone. Reports group sales into days,
+	// weeks and months in this zone.
+	BusinessLocation *time.Location
+
+	// JWTSecret signs access tokens. It must be set and at least 32 bytes.
+	JWTSecret       string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	// CORSAllowedOrigins lists the browser origins allowed to call the API
+	// with credentials.
+	CORSAllowedOrigins []string
+
+	// TrashRetention is how long deleted sales stay restorable before they are
+	// purged. The purge runs every PurgeInterval.
+	TrashRetention time.Duration
+	PurgeInterval  time.Duration
+
+	// MetricsToken must be sent as a bearer token to scrape /metrics, which
+	// shows revenue and debt totals.
+	MetricsToken string
+
+	// IdempotencyKeyTTL is how long a retried request with the same
+	// Idempotency-Key gets the first response back. Expired keys are removed
+	// every PurgeInterval.
+	IdempotencyKeyTTL time.Duration
 }
 
+// LoadConfig reads the configuration from the environment and .env. Every
+// invalid or missing setting is reported in the returned error.
 func LoadConfig() (*Config, error) {
 	_ = godotenv.Load()
 
+	var errs []error
+	duration := func(key string, defaultValue time.Duration) time.Duration {
+		value, err := getEnvDuration(key, defaultValue)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		return value
+	}
+	number := func(key string, defaultValue int) int {
+		value, err := getEnvInt(key, defaultValue)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		return value
+	}
+
 	config := &Config{
-		DBHost:     getEnv("DB_HOST", "localhost"),
-		DBPort:     getEnv("DB_PORT", "5432"),
-		DBUser:     getEnv("DB_USERNAME", "username"),
-		DBPassword: getEnv("DB_PASSWORD", "password"),
-		DBName:     getEnv("DB_DATABASE", "database"),
+		DBMaxOpenConns:    number("DB_MAX_OPEN_CONNS", 20),
+		DBMaxIdleConns:    number("DB_MAX_IDLE_CONNS", 10),
+		DBConnMaxLifetime: duration("DB_CONN_MAX_LIFETIME", 30*time.Minute),
+		DBConnMaxIdleTime: duration("DB_CONN_MAX_IDLE_TIME", 5*time.Minute),
+
 		ServerPort: getEnv("PORT", "8080"),
+
+		LogFormat: getEnv("LOG_FORMAT", "text"),
+
+		DBQueryTimeout: duration("DB_QUERY_TIMEOUT", 5*time.Second),
+
+		AutoMigrate: getEnvBool("DB_AUTO_MIGRATE", false),
+
+		JWTSecret:       os.Getenv("JWT_SECRET"),
+		AccessTokenTTL:  duration("JWT_ACCESS_TTL", 15*time.Minute),
+		RefreshTokenTTL: duration("JWT_REFRESH_TTL", 30*24*time.Hour),
+
+		CORSAllowedOrigins: getEnvList("CORS_ALLOWED_ORIGINS", "http://localhost,http://localhost:5173"),
+
+		TrashRetention: duration("SALES_TRASH_RETENTION", 30*24*time.Hour),
+		PurgeInterval:  duration("SALES_PURGE_INTERVAL", time.Hour),
+
+		IdempotencyKeyTTL: duration("IDEMPOTENCY_KEY_TTL", 24*time.Hour),
+
+		MetricsToken: os.Getenv("METRICS_TOKEN"),
+	}
+
+	databaseURL, err := loadDatabaseURL()
+	if err != nil {
+		errs = append(errs, err)
+	}
+	config.DatabaseURL = databaseURL
+
+	if config.DBMaxOpenConns < 1 {
+		errs = append(errs, errors.New("DB_MAX_OPEN_CONNS must be at least 1"))
+	}
+	if config.DBMaxIdleConns > config.DBMaxOpenConns {
+		errs = append(errs, errors.New("DB_MAX_IDLE_CONNS must not be more than DB_MAX_OPEN_CONNS"))
+	}
+
+	if config.LogFormat != "text" && config.LogFormat != "json" {
+		errs = append(errs, fmt.Errorf("LOG_FORMAT must be text or json, got %q", config.LogFormat))
+	}
+	if err := config.LogLevel.UnmarshalText([]byte(getEnv("LOG_LEVEL", "info"))); err != nil {
+		errs = append(errs, fmt.Errorf("LOG_LEVEL must be debug, info, warn or error: %w", err))
+	}
+
+	if len(config.JWTSecret) < 32 {
+		errs = append(errs, errors.New("JWT_SECRET must be set to at least 32 characters"))
+	}
+	if len(config.MetricsToken) < 16 {
+		errs = append(errs, errors.New("METRICS_TOKEN must be set to at least 16 characters"))
+	}
+
+	timezone := getEnv("BUSINESS_TIMEZONE", "Asia/Jakarta")
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("invalid BUSINESS_TIMEZONE %q: %w", timezone, err))
+	}
+	config.BusinessLocation = location
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
 	}
 
 	return config, nil
@@ -37,3 +162,48 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+func getEnvBool(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue, nil
+	}
+
+	value, err := time.ParseDuration(raw)
+	if err != nil || value <= 0 {
+		return defaultValue, fmt.Errorf("%s must be a positive duration such as 30s or 5m, got %q", key, raw)
+	}
+	return value, nil
+}
+
+func getEnvInt(key string, defaultValue int) (int, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue, nil
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 0 {
+		return defaultValue, fmt.Errorf("%s must be a whole number, got %q", key, raw)
+	}
+	return value, nil
+}
+
+// getEnvList splits a comma separated value, dropping empty entries.
+func getEnvList(key, defaultValue string) []string {
+	var values []string
+	for _, value := range strings.Split(getEnv(key, defaultValue), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -4,19 +4,20 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/url"
 	"pencatatan/internal/config"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	_ "github.com/joho/godotenv/autoload"
 )
 
 type Service interface {
-	Health() map[string]string
+	Health(ctx context.Context) map[string]string
 	Close() error
 	DB() *sql.DB
 }
@@ -33,17 +34,16 @@ var (
 
 func New(cfg *config.Config) (Service, error) {
 	dbOne.Do(func() {
-		connStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable", cfg.DBUser, cfg.DBPassword, cfg.DBHost, cfg.DBPort, cfg.DBName)
-		db, err := sql.Open("pgx", connStr)
+		db, err := sql.Open("pgx", connString("postgres", cfg))
 		if err != nil {
 			initErr = err
 			return
 		}
 
-		db.SetMaxOpenConns(20)
-		db.SetMaxIdleConns(10)
-		db.SetConnMaxLifetime(time.Hour)
-		db.SetConnMaxLifetime(30 * time.Minute)
+		db.SetMaxOpenConns(cfg.DBMaxOpenConns)
+		db.SetMaxIdleConns(cfg.DBMaxIdleConns)
+		db.SetConnMaxLifetime(cfg.DBConnMaxLifetime)
+		db.SetConnMaxIdleTime(cfg.DBConnMaxIdleTime)
 
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
@@ -56,7 +56,10 @@ func New(cfg *config.Config) (Service, error) {
 			db: db,
 		}
 
-		log.Println("Database connection pool initialized")
+		slog.Info("database connection pool initialized",
+			"max_open_conns", cfg.DBMaxOpenConns,
+			"max_idle_conns", cfg.DBMaxIdleConns,
+		)
 	})
 
 	if initErr != nil {
@@ -66,8 +69,31 @@ func New(cfg *config.Config) (Service, error) {
 	return dbInstance, nil
 }
 
-func (s *service) Health() map[string]string {
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+// connString returns the configured database URL with the scheme the driver
+// expects. LoadConfig has already checked that the URL parses. A keyword/value
+// DSN has no scheme and is returned as given.
+func connString(scheme string, cfg *config.Config) string {
+	if isKeywordDSN(cfg) {
+		return cfg.DatabaseURL
+	}
+	u, err := url.Parse(cfg.DatabaseURL)
+	if err != nil {
+		return cfg.DatabaseURL
+	}
+	u.Scheme = scheme
+	return u.String()
+}
+
+// isKeywordDSN reports whether DATABASE_URL is a keyword/value DSN such as
+// "host=db user=app" rather than a URL.
+func isKeywordDSN(cfg *config.Config) bool {
+	return !strings.Contains(cfg.DatabaseURL, "://")
+}
+
+// Health pings the database and reports pool statistics. Warnings about the
+// pool are logged with ctx, so they carry the ID of the request that asked.
+func (s *service) Health(ctx context.Context) map[string]string {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
 	defer cancel()
 
 	stats := make(map[string]string)
@@ -76,7 +102,7 @@ func (s *service) Health() map[string]string {
 	if err != nil {
 		stats["status"] = "down"
 		stats["error"] = fmt.Sprintf("db down: %v", err)
-		log.Printf("db down: %v", err)
+		slog.ErrorContext(ctx, "database down", "error", err)
 		return stats
 	}
 
@@ -108,6 +134,15 @@ func (s *service) Health() map[string]string {
 		stats["message"] = "Many connections are being closed due to max lifetime, consider increasing max lifetime or revising the connection usage pattern."
 	}
 
+	if stats["message"] != "It's healthy" {
+		slog.WarnContext(ctx, stats["message"],
+			"open_connections", dbStats.OpenConnections,
+			"in_use", dbStats.InUse,
+			"idle", dbStats.Idle,
+			"wait_count", dbStats.WaitCount,
+		)
+	}
+
 	return stats
 }
 
@@ -116,6 +151,6 @@ func (s *service) DB() *sql.DB {
 }
 
 func (s *service) Close() error {
-	log.Printf("Disconnected from database")
+	slog.Info("disconnected from database")
 	return s.db.Close()
 }
@@ -0,0 +1,136 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"pencatatan/internal/config"
+	"pencatatan/migrations"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+	pgx5 "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+type Migrator struct {
+	m *migrate.Migrate
+}
+
+// NewMigrator opens a dedicated connection for golang-migrate so closing the
+// migrator never touches the shared connection pool returned by New.
+func NewMigrator(cfg *config.Config) (*Migrator, error) {
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load embedded migrations: %w", err)
+	}
+
+	m, err := newMigrate(src, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize migrator: %w", err)
+	}
+	m.Log = migrateLogger{}
+
+	return &Migrator{m: m}, nil
+}
+
+// newMigrate connects golang-migrate to the database. The driver only opens
+// URLs, so a keyword/value DSN gets its own *sql.DB, which closing the
+// migrator closes as well.
+func newMigrate(src source.Driver, cfg *config.Config) (*migrate.Migrate, error) {
+	if !isKeywordDSN(cfg) {
+		return migrate.NewWithSourceInstance("iofs", src, connString("pgx5", cfg))
+	}
+
+	db, err := sql.Open("pgx", cfg.DatabaseURL)
+	if err != nil {
+		return nil, err
+	}
+	driver, err := pgx5.WithInstance(db, &pgx5.Config{})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return migrate.NewWithInstance("iofs", src, "pgx5", driver)
+}
+
+// Up applies all pending migrations, or at most steps migrations when steps > 0.
+func (mg *Migrator) Up(steps int) error {
+	var err error
+	if steps > 0 {
+		err = mg.m.Steps(steps)
+	} else {
+		err = mg.m.Up()
+	}
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	return err
+}
+
+// Down rolls back the given number of migrations.
+func (mg *Migrator) Down(steps int) error {
+	if steps <= 0 {
+		return errors.New("steps must be greater than 0")
+	}
+	err := mg.m.Steps(-steps)
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	return err
+}
+
+// Force sets the migration version without running any migration, which is
+// how a dirty database is recovered after a failed migration was fixed by hand.
+func (mg *Migrator) Force(version int) error {
+	return mg.m.Force(version)
+}
+
+// Status returns the current version and whether the last migration failed
+// halfway. A database without any applied migration reports version 0.
+func (mg *Migrator) Status() (uint, bool, error) {
+	version, dirty, err := mg.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+func (mg *Migrator) Close() error {
+	srcErr, dbErr := mg.m.Close()
+	return errors.Join(srcErr, dbErr)
+}
+
+// RunMigrations applies every pending migration, used on startup when
+// DB_AUTO_MIGRATE is enabled.
+func RunMigrations(cfg *config.Config) error {
+	mg, err := NewMigrator(cfg)
+	if err != nil {
+		return err
+	}
+	defer mg.Close()
+
+	if err := mg.Up(0); err != nil {
+		return fmt.Errorf("failed to apply migrations: %w", err)
+	}
+
+	version, _, err := mg.Status()
+	if err != nil {
+		return err
+	}
+	slog.Info("database migrated", "version", version)
+
+	return nil
+}
+
+type migrateLogger struct{}
+
+func (migrateLogger) Printf(format string, v ...interface{}) {
+	slog.Info(strings.TrimSpace(fmt.Sprintf(format, v...)), "component", "migrate")
+}
+
+func (migrateLogger) Verbose() bool {
+	return false
+}
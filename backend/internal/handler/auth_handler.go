@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"pencatatan/internal/models"
+	"pencatatan/internal/service"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// userContextKey is the gin context key RequireAuth stores the user under.
+const userContextKey = "user"
+
+type AuthHandler struct {
+	service service.AuthService
+}
+
+func NewAuthHandler(service service.AuthService) *AuthHandler {
+	return &AuthHandler{
+		service: service,
+	}
+}
+
+var errMissingToken = &service.Error{
+	Kind:    service.KindUnauthorized,
+	Code:    "missing_token",
+	Message: "missing bearer token",
+}
+
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req models.LoginRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	tokens, err := h.service.Login(c.Request.Context(), &req)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Message: "Logged in successfully",
+		Data:    tokens,
+	})
+}
+
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req models.RefreshTokenRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	tokens, err := h.service.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    tokens,
+	})
+}
+
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var req models.RefreshTokenRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	if err := h.service.Logout(c.Request.Context(), req.RefreshToken); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Message: "Logged out successfully",
+	})
+}
+
+// Me returns the logged in user.
+func (h *AuthHandler) Me(c *gin.Context) {
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    currentUser(c),
+	})
+}
+
+// RequireAuth rejects requests without a valid "Authorization: Bearer" access
+// token and stores the token's user in the context for the next handlers.
+func (h *AuthHandler) RequireAuth(c *gin.Context) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || strings.TrimSpace(token) == "" {
+		c.Header("WWW-Authenticate", "Bearer")
+		_ = c.Error(errMissingToken)
+		c.Abort()
+		return
+	}
+
+	user, err := h.service.Authenticate(c.Request.Context(), strings.TrimSpace(token))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidToken) {
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+		}
+		_ = c.Error(err)
+		c.Abort()
+		return
+	}
+
+	c.Set(userContextKey, user)
+	c.Next()
+}
+
+// RequirePermission rejects users whose role lacks the permission. It must run
+// after RequireAuth.
+func (h *AuthHandler) RequirePermission(permission models.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if user := currentUser(c); user == nil || !user.Can(permission) {
+			_ = c.Error(service.ErrForbidden)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// currentUser returns the user set by RequireAuth, or nil on public routes.
+func currentUser(c *gin.Context) *models.User {
+	user, _ := c.Get(userContextKey)
+	u, _ := user.(*models.User)
+	return u
+}
+
+func currentUserID(c *gin.Context) *uuid.UUID {
+	if user := currentUser(c); user != nil {
+		return &user.ID
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"pencatatan/internal/models"
+	"pencatatan/internal/service"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func setupAuthRouter(handler *AuthHandler, saleHandler *SaleHandler) *gin.Engine {
+	router := gin.New()
+	router.Use(ErrorHandler)
+	router.POST("/auth/login", handler.Login)
+	router.POST("/auth/refresh", handler.Refresh)
+	router.POST("/auth/logout", handler.Logout)
+
+	secured := router.Group("", handler.RequireAuth)
+	secured.GET("/auth/me", handler.Me)
+	secured.POST("/sales", saleHandler.CreateSale)
+	return router
+}
+
+func TestLogin_Unauthorized(t *testing.T) {
+	mockService := &service.MockAuthService{
+		LoginFunc: func(ctx context.Context, req *models.LoginRequest) (*models.AuthTokens, error) {
+			return nil, service.ErrInvalidCredentials
+		},
+	}
+	router := setupAuthRouter(NewAuthHandler(mockService), NewSaleHandler(&service.MockSaleService{}))
+
+	body, _ := json.Marshal(models.LoginRequest{Username: "kasir", Password: "salah"})
+	req, _ := http.NewRequest("POST", "/auth/login", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestRequireAuth_MissingToken(t *testing.T) {
+	called := false
+	saleService := &service.MockSaleService{
+		CreateSaleFunc: func(ctx context.Context, req *models.CreateSalesRequest) (*models.Sale, error) {
+			called = true
+			return &models.Sale{}, nil
+		},
+	}
+	router := setupAuthRouter(NewAuthHandler(&service.MockAuthService{}), NewSaleHandler(saleService))
+
+	req, _ := http.NewRequest("POST", "/sales", bytes.NewBufferString(`{"items":[{"product":"Beras","quantity":1,"price":12000}]}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+	if w.Header().Get("WWW-Authenticate") == "" {
+		t.Error("Expected a WWW-Authenticate header")
+	}
+	if called {
+		t.Error("Expected the sale handler not to run")
+	}
+}
+
+func TestRequireAuth_InvalidToken(t *testing.T) {
+	mockService := &service.MockAuthService{
+		AuthenticateFunc: func(ctx context.Context, accessToken string) (*models.User, error) {
+			return nil, service.ErrInvalidToken
+		},
+	}
+	router := setupAuthRouter(NewAuthHandler(mockService), NewSaleHandler(&service.MockSaleService{}))
+
+	req, _ := http.NewRequest("GET", "/auth/me", nil)
+	req.Header.Set("Authorization", "Bearer expired")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestRequireAuth_RecordsCreatedBy(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Username: "kasir", IsActive: true}
+	authService := &service.MockAuthService{
+		AuthenticateFunc: func(ctx context.Context, accessToken string) (*models.User, error) {
+			if accessToken != "valid" {
+				return nil, service.ErrInvalidToken
+			}
+			return user, nil
+		},
+	}
+
+	var createdBy *uuid.UUID
+	saleService := &service.MockSaleService{
+		CreateSaleFunc: func(ctx context.Context, req *models.CreateSalesRequest) (*models.Sale, error) {
+			createdBy = req.CreatedBy
+			return &models.Sale{ID: uuid.New(), CreatedBy: req.CreatedBy}, nil
+		},
+	}
+	router := setupAuthRouter(NewAuthHandler(authService), NewSaleHandler(saleService))
+
+	// created_by in the body must be ignored in favour of the logged in user.
+	body := `{"items":[{"product":"Beras","quantity":1,"price":12000}],"amount_received":12000,"created_by":"` + uuid.NewString() + `"}`
+	req, _ := http.NewRequest("POST", "/sales", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer valid")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	if createdBy == nil || *createdBy != user.ID {
+		t.Errorf("Expected created_by %s, got %v", user.ID, createdBy)
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	roles := map[models.Role]int{
+		models.RoleOwner:   http.StatusOK,
+		models.RoleCashier: http.StatusOK,
+		models.RoleViewer:  http.StatusForbidden,
+	}
+
+	for role, expected := range roles {
+		authService := &service.MockAuthService{
+			AuthenticateFunc: func(ctx context.Context, accessToken string) (*models.User, error) {
+				return &models.User{ID: uuid.New(), Role: role, IsActive: true}, nil
+			},
+		}
+		handler := NewAuthHandler(authService)
+
+		router := gin.New()
+		router.Use(ErrorHandler)
+		router.GET("/sales", handler.RequireAuth, handler.RequirePermission(models.PermissionViewSales), func(c *gin.Context) {
+			c.JSON(http.StatusOK, Response{Success: true})
+		})
+
+		req, _ := http.NewRequest("GET", "/sales", nil)
+		req.Header.Set("Authorization", "Bearer valid")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != expected {
+			t.Errorf("%s: expected status %d, got %d", role, expected, w.Code)
+		}
+	}
+}
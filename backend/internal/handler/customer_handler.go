@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"net/http"
+	"pencatatan/internal/models"
+	"pencatatan/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CustomerHandler struct {
+	service service.CustomerService
+}
+
+func NewCustomerHandler(service service.CustomerService) *CustomerHandler {
+	return &CustomerHandler{
+		service: service,
+	}
+}
+
+func (h *CustomerHandler) CreateCustomer(c *gin.Context) {
+	var req models.CreateCustomerRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	customer, err := h.service.CreateCustomer(c.Request.Context(), &req)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, Response{
+		Success: true,
+		Message: "Customer created successfully",
+		Data:    customer,
+	})
+}
+
+func (h *CustomerHandler) GetCustomerByID(c *gin.Context) {
+	id := c.Param("id")
+
+	customer, err := h.service.GetCustomerByID(c.Request.Context(), id)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    customer,
+	})
+}
+
+func (h *CustomerHandler) GetAllCustomers(c *gin.Context) {
+	customers, err := h.service.GetAllCustomers(c.Request.Context(), c.Query("q"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    customers,
+	})
+}
+
+func (h *CustomerHandler) UpdateCustomer(c *gin.Context) {
+	id := c.Param("id")
+	var req models.UpdateCustomerRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	customer, err := h.service.UpdateCustomer(c.Request.Context(), id, &req)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Message: "Customer updated successfully",
+		Data:    customer,
+	})
+}
+
+func (h *CustomerHandler) DeleteCustomer(c *gin.Context) {
+	id := c.Param("id")
+
+	err := h.service.DeleteCustomer(c.Request.Context(), id)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Message: "Customer deleted successfully",
+	})
+}
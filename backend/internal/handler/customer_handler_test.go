@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"pencatatan/internal/models"
+	"pencatatan/internal/service"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func setupCustomerRouter(handler *CustomerHandler) *gin.Engine {
+	router := gin.New()
+	router.Use(ErrorHandler)
+	router.POST("/customers", handler.CreateCustomer)
+	router.GET("/customers", handler.GetAllCustomers)
+	router.GET("/customers/:id", handler.GetCustomerByID)
+	router.PUT("/customers/:id", handler.UpdateCustomer)
+	router.DELETE("/customers/:id", handler.DeleteCustomer)
+	return router
+}
+
+func TestCreateCustomer_Success(t *testing.T) {
+	mockService := &service.MockCustomerService{
+		CreateCustomerFunc: func(ctx context.Context, req *models.CreateCustomerRequest) (*models.Customer, error) {
+			return &models.Customer{ID: uuid.New(), Name: req.Name}, nil
+		},
+	}
+
+	router := setupCustomerRouter(NewCustomerHandler(mockService))
+
+	req, _ := http.NewRequest("POST", "/customers", bytes.NewBufferString(`{"name":"Bu Sari","phone":"0812","credit_limit":250000}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+}
+
+func TestGetAllCustomers_PassesQuery(t *testing.T) {
+	var gotQuery string
+	mockService := &service.MockCustomerService{
+		GetAllCustomersFunc: func(ctx context.Context, q string) ([]*models.Customer, error) {
+			gotQuery = q
+			return []*models.Customer{}, nil
+		},
+	}
+
+	router := setupCustomerRouter(NewCustomerHandler(mockService))
+
+	req, _ := http.NewRequest("GET", "/customers?q=sari", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	if gotQuery != "sari" {
+		t.Errorf("Expected query sari, got %q", gotQuery)
+	}
+}
+
+func TestGetCustomerByID_NotFound(t *testing.T) {
+	mockService := &service.MockCustomerService{
+		GetCustomerByIDFunc: func(ctx context.Context, id string) (*models.Customer, error) {
+			return nil, service.ErrCustomerNotFound
+		},
+	}
+
+	router := setupCustomerRouter(NewCustomerHandler(mockService))
+
+	req, _ := http.NewRequest("GET", "/customers/"+uuid.New().String(), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
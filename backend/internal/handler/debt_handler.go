@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"net/http"
+	"pencatatan/internal/models"
+	"pencatatan/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DebtHandler struct {
+	service service.DebtService
+}
+
+func NewDebtHandler(service service.DebtService) *DebtHandler {
+	return &DebtHandler{
+		service: service,
+	}
+}
+
+func (h *DebtHandler) PaySale(c *gin.Context) {
+	id := c.Param("id")
+	var req models.CreateDebtPaymentRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	result, err := h.service.PaySale(c.Request.Context(), id, &req)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, Response{
+		Success: true,
+		Message: "Payment recorded successfully",
+		Data:    result,
+	})
+}
+
+func (h *DebtHandler) PayCustomer(c *gin.Context) {
+	id := c.Param("id")
+	var req models.CreateDebtPaymentRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	result, err := h.service.PayCustomer(c.Request.Context(), id, &req)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, Response{
+		Success: true,
+		Message: "Payment recorded successfully",
+		Data:    result,
+	})
+}
+
+func (h *DebtHandler) GetSaleDebt(c *gin.Context) {
+	id := c.Param("id")
+
+	debt, err := h.service.GetSaleDebt(c.Request.Context(), id)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    debt,
+	})
+}
+
+func (h *DebtHandler) GetCustomerBalance(c *gin.Context) {
+	id := c.Param("id")
+
+	balance, err := h.service.GetCustomerBalance(c.Request.Context(), id)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    balance,
+	})
+}
+
+func (h *DebtHandler) GetOpenDebts(c *gin.Context) {
+	debts, err := h.service.GetOpenDebts(c.Request.Context(), c.Query("customer_id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    debts,
+	})
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"pencatatan/internal/models"
+	"pencatatan/internal/service"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func setupDebtRouter(handler *DebtHandler) *gin.Engine {
+	router := gin.New()
+	router.Use(ErrorHandler)
+	router.POST("/sales/:id/payments", handler.PaySale)
+	router.GET("/sales/:id/debt", handler.GetSaleDebt)
+	router.POST("/customers/:id/payments", handler.PayCustomer)
+	router.GET("/customers/:id/balance", handler.GetCustomerBalance)
+	router.GET("/debts", handler.GetOpenDebts)
+	return router
+}
+
+func TestPaySale_Success(t *testing.T) {
+	mockService := &service.MockDebtService{
+		PaySaleFunc: func(ctx context.Context, saleID string, req *models.CreateDebtPaymentRequest) (*models.SalePaymentResult, error) {
+			return &models.SalePaymentResult{
+				Payment: &models.DebtPayment{ID: uuid.New(), Amount: req.Amount},
+				Debt:    &models.SaleDebt{},
+			}, nil
+		},
+	}
+
+	router := setupDebtRouter(NewDebtHandler(mockService))
+
+	req, _ := http.NewRequest("POST", "/sales/"+uuid.New().String()+"/payments", bytes.NewBufferString(`{"amount":5000}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+}
+
+func TestPaySale_ZeroAmount(t *testing.T) {
+	router := setupDebtRouter(NewDebtHandler(&service.MockDebtService{}))
+
+	req, _ := http.NewRequest("POST", "/sales/"+uuid.New().String()+"/payments", bytes.NewBufferString(`{"amount":0}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestPayCustomer_Overpayment(t *testing.T) {
+	mockService := &service.MockDebtService{
+		PayCustomerFunc: func(ctx context.Context, customerID string, req *models.CreateDebtPaymentRequest) (*models.CustomerPaymentResult, error) {
+			return nil, service.ErrPaymentExceedsDebt
+		},
+	}
+
+	router := setupDebtRouter(NewDebtHandler(mockService))
+
+	req, _ := http.NewRequest("POST", "/customers/"+uuid.New().String()+"/payments", bytes.NewBufferString(`{"amount":999999}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+}
+
+func TestGetSaleDebt_NotFound(t *testing.T) {
+	mockService := &service.MockDebtService{
+		GetSaleDebtFunc: func(ctx context.Context, saleID string) (*models.SaleDebt, error) {
+			return nil, service.ErrSaleNotFound
+		},
+	}
+
+	router := setupDebtRouter(NewDebtHandler(mockService))
+
+	req, _ := http.NewRequest("GET", "/sales/"+uuid.New().String()+"/debt", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"pencatatan/internal/service"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError describes one invalid field of a request body or query. Field is
+// the JSON or query name, with the index for list items, e.g. items[0].price.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+const (
+	codeInvalidRequest  = "invalid_request"
+	codeRequestTooLarge = "request_too_large"
+	codeInternal        = "internal_error"
+)
+
+var errorKindStatus = map[service.ErrorKind]int{
+	service.KindValidation:           http.StatusBadRequest,
+	service.KindNotFound:             http.StatusNotFound,
+	service.KindConflict:             http.StatusConflict,
+	service.KindUnprocessable:        http.StatusUnprocessableEntity,
+	service.KindUnauthorized:         http.StatusUnauthorized,
+	service.KindForbidden:            http.StatusForbidden,
+	service.KindPreconditionRequired: http.StatusPreconditionRequired,
+	service.KindPreconditionFailed:   http.StatusPreconditionFailed,
+}
+
+// ErrorHandler answers requests whose handler failed. Handlers and middleware
+// report an error with c.Error and return, and ErrorHandler turns the last
+// one into a JSON Response with a status, code and message that match the
+// kind of error. Request binding errors are marked with gin.ErrorTypeBind.
+// Internal errors are logged and their details kept from the client.
+func ErrorHandler(c *gin.Context) {
+	c.Next()
+	writeError(c)
+}
+
+// writeError writes the error response, unless there is no error or the
+// response was already started.
+func writeError(c *gin.Context) {
+	if len(c.Errors) == 0 || c.Writer.Written() {
+		return
+	}
+
+	status, response := errorResponse(c.Errors.Last())
+	if status == http.StatusInternalServerError {
+		slog.ErrorContext(c.Request.Context(), "request failed",
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"error", c.Errors.Last().Err,
+		)
+	}
+	c.JSON(status, response)
+}
+
+func errorResponse(ginErr *gin.Error) (int, Response) {
+	err := ginErr.Err
+	response := Response{Success: false, Error: err.Error()}
+
+	var serviceErr *service.Error
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		response.Code = codeRequestTooLarge
+		return http.StatusRequestEntityTooLarge, response
+	case ginErr.IsType(gin.ErrorTypeBind):
+		response.Code = codeInvalidRequest
+		response.Details = fieldErrors(err)
+		return http.StatusBadRequest, response
+	case errors.As(err, &serviceErr):
+		if status, ok := errorKindStatus[serviceErr.Kind]; ok {
+			response.Code = serviceErr.Code
+			return status, response
+		}
+	}
+
+	return http.StatusInternalServerError, Response{
+		Success: false,
+		Error:   "internal server error",
+		Code:    codeInternal,
+	}
+}
+
+// fieldErrors lists the failed validation rules of a binding error, or nil
+// when the error is not about validation, like malformed JSON.
+func fieldErrors(err error) []FieldError {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return nil
+	}
+
+	details := make([]FieldError, 0, len(validationErrs))
+	for _, fieldErr := range validationErrs {
+		details = append(details, FieldError{
+			Field:   fieldName(fieldErr.Namespace()),
+			Rule:    fieldErr.Tag(),
+			Message: ruleMessage(fieldErr),
+		})
+	}
+	return details
+}
+
+// fieldName turns a validator namespace such as
+// "CreateSalesRequest.Items[0].AmountReceived" into the name used in the
+// request, "items[0].amount_received". Request fields are named after their
+// struct fields in snake case throughout the API.
+func fieldName(namespace string) string {
+	parts := strings.Split(namespace, ".")
+	if len(parts) > 1 {
+		parts = parts[1:]
+	}
+	for i, part := range parts {
+		parts[i] = snakeCase(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+// snakeCase converts a Go identifier to snake case, keeping initialisms
+// together: CustomerID becomes customer_id and SKU becomes sku.
+func snakeCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+func ruleMessage(fieldErr validator.FieldError) string {
+	param := fieldErr.Param()
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "required_without":
+		return fmt.Sprintf("is required when %s is missing", snakeCase(param))
+	case "min":
+		return fmt.Sprintf("must be at least %s", param)
+	case "max":
+		return fmt.Sprintf("must be at most %s", param)
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", param)
+	case "gte":
+		return fmt.Sprintf("must be %s or more", param)
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", strings.ReplaceAll(param, " ", ", "))
+	case "iso4217":
+		return "must be an ISO 4217 currency code"
+	case "uuid":
+		return "must be a UUID"
+	}
+	return fmt.Sprintf("failed on the '%s' rule", fieldErr.Tag())
+}
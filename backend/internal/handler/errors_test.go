@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"pencatatan/internal/models"
+	"pencatatan/internal/service"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestErrorHandler_StatusByKind(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{service.ErrInvalidID, http.StatusBadRequest, "invalid_id"},
+		{service.ErrSaleNotFound, http.StatusNotFound, "sale_not_found"},
+		{service.ErrForbidden, http.StatusForbidden, "forbidden"},
+		{service.ErrSaleModified, http.StatusPreconditionFailed, "sale_modified"},
+		{errors.New("connection refused"), http.StatusInternalServerError, "internal_error"},
+	}
+
+	for _, tt := range tests {
+		mockService := &service.MockSaleService{
+			GetSaleByIDFunc: func(ctx context.Context, id string) (*models.Sale, error) {
+				return nil, tt.err
+			},
+		}
+		router := setupRouter(NewSaleHandler(mockService))
+
+		req, _ := http.NewRequest("GET", "/sales/"+uuid.New().String(), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("%v: expected status %d, got %d", tt.err, tt.status, w.Code)
+		}
+
+		var response Response
+		json.Unmarshal(w.Body.Bytes(), &response)
+
+		if response.Success || response.Code != tt.code {
+			t.Errorf("%v: expected code %q, got %+v", tt.err, tt.code, response)
+		}
+	}
+}
+
+func TestErrorHandler_HidesInternalErrors(t *testing.T) {
+	mockService := &service.MockSaleService{
+		GetSaleByIDFunc: func(ctx context.Context, id string) (*models.Sale, error) {
+			return nil, errors.New("pq: password authentication failed")
+		},
+	}
+	router := setupRouter(NewSaleHandler(mockService))
+
+	req, _ := http.NewRequest("GET", "/sales/"+uuid.New().String(), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if bytes.Contains(w.Body.Bytes(), []byte("password")) {
+		t.Errorf("Expected the cause to be hidden, got %s", w.Body.String())
+	}
+}
+
+func TestErrorHandler_FieldDetails(t *testing.T) {
+	router := setupRouter(NewSaleHandler(&service.MockSaleService{}))
+
+	body := `{"items":[{"product":"Beras","quantity":0,"price":1000}],"currency":"rupiah"}`
+	req, _ := http.NewRequest("POST", "/sales", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	var response Response
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	if response.Code != "invalid_request" {
+		t.Errorf("Expected code invalid_request, got %q", response.Code)
+	}
+
+	fields := map[string]string{}
+	for _, detail := range response.Details {
+		fields[detail.Field] = detail.Rule
+	}
+	if fields["items[0].quantity"] != "required" || fields["currency"] != "iso4217" {
+		t.Errorf("Expected quantity and currency details, got %+v", response.Details)
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"AmountReceived": "amount_received",
+		"CustomerID":     "customer_id",
+		"SKU":            "sku",
+		"Items[0]":       "items[0]",
+		"IsDebt":         "is_debt",
+	}
+
+	for in, want := range tests {
+		if got := snakeCase(in); got != want {
+			t.Errorf("snakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
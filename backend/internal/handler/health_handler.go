@@ -16,5 +16,5 @@ func NewHealthHandler(db database.Service) *HealthHandler {
 }
 
 func (h *HealthHandler) Check(c *gin.Context) {
-	c.JSON(http.StatusOK, h.db.Health())
+	c.JSON(http.StatusOK, h.db.Health(c.Request.Context()))
 }
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"pencatatan/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxIdempotencyKeyLength = 255
+
+var errIdempotencyKeyTooLong = &service.Error{
+	Kind:    service.KindValidation,
+	Code:    "idempotency_key_too_long",
+	Message: "Idempotency-Key must be at most 255 characters",
+}
+
+type IdempotencyHandler struct {
+	service service.IdempotencyService
+}
+
+func NewIdempotencyHandler(service service.IdempotencyService) *IdempotencyHandler {
+	return &IdempotencyHandler{
+		service: service,
+	}
+}
+
+// responseRecorder passes the response through while keeping a copy of the
+// body.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotent makes a request sent with an Idempotency-Key header safe to
+// retry: a repeat of it gets the first response back, status code included,
+// instead of running again. Keys are per user, so it must run after
+// RequireAuth. Requests without the header are handled normally, and a
+// response with a 5xx status is not kept, so the client can retry it.
+func (h *IdempotencyHandler) Idempotent(c *gin.Context) {
+	key := c.GetHeader("Idempotency-Key")
+	userID := currentUserID(c)
+	if key == "" || userID == nil {
+		c.Next()
+		return
+	}
+
+	if len(key) > maxIdempotencyKeyLength {
+		_ = c.Error(errIdempotencyKeyTooLong)
+		c.Abort()
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		c.Abort()
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	sum := sha256.Sum256([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n" + string(body)))
+
+	claim, err := h.service.Begin(c.Request.Context(), *userID, key, hex.EncodeToString(sum[:]))
+	if err != nil {
+		if errors.Is(err, service.ErrIdempotencyKeyInProgress) {
+			c.Header("Retry-After", "1")
+		}
+		_ = c.Error(err)
+		c.Abort()
+		return
+	}
+
+	if claim.Completed() {
+		c.Header("Idempotent-Replayed", "true")
+		c.Data(*claim.StatusCode, "application/json; charset=utf-8", claim.Response)
+		c.Abort()
+		return
+	}
+
+	recorder := &responseRecorder{ResponseWriter: c.Writer}
+	c.Writer = recorder
+
+	// The outcome is saved even if the client has gone away by now; that
+	// client is the one most likely to retry.
+	ctx := context.WithoutCancel(c.Request.Context())
+
+	// The claim is renewed while the request runs, however long it takes.
+	stop := h.service.KeepAlive(ctx, claim)
+
+	// The key is released unless the response is stored, so a request that
+	// panics or fails on the server side can be retried with the same key.
+	completed := false
+	defer func() {
+		stop()
+		if completed {
+			return
+		}
+		if err := h.service.Release(ctx, claim); err != nil {
+			slog.ErrorContext(ctx, "release idempotency key", "error", err)
+		}
+	}()
+
+	c.Next()
+	stop()
+	// Render a failed request's error now, so it is stored like any other
+	// response.
+	writeError(c)
+
+	if recorder.Status() >= http.StatusInternalServerError {
+		return
+	}
+
+	if err := h.service.Complete(ctx, claim, recorder.Status(), recorder.body.Bytes()); err != nil {
+		slog.ErrorContext(ctx, "store idempotent response", "error", err)
+		return
+	}
+	completed = true
+}
@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"pencatatan/internal/models"
+	"pencatatan/internal/service"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func setupIdempotencyRouter(handler *IdempotencyHandler, saleHandler *SaleHandler) *gin.Engine {
+	user := &models.User{ID: uuid.New(), Role: models.RoleCashier, IsActive: true}
+
+	router := gin.New()
+	router.Use(ErrorHandler)
+	router.POST("/sales", func(c *gin.Context) {
+		c.Set(userContextKey, user)
+	}, handler.Idempotent, saleHandler.CreateSale)
+	return router
+}
+
+const idempotentSaleBody = `{"items":[{"product":"Beras","quantity":1,"price":12000}],"amount_received":12000}`
+
+func TestIdempotent_StoresResponse(t *testing.T) {
+	var storedStatus int
+	var storedBody []byte
+	idempotencyService := &service.MockIdempotencyService{
+		CompleteFunc: func(ctx context.Context, claim *models.IdempotencyKey, statusCode int, response []byte) error {
+			storedStatus = statusCode
+			storedBody = response
+			return nil
+		},
+		ReleaseFunc: func(ctx context.Context, claim *models.IdempotencyKey) error {
+			t.Error("Expected the key not to be released")
+			return nil
+		},
+	}
+	saleService := &service.MockSaleService{
+		CreateSaleFunc: func(ctx context.Context, req *models.CreateSalesRequest) (*models.Sale, error) {
+			total, _ := models.ItemsTotal(req.Items)
+			return &models.Sale{ID: uuid.New(), Total: total}, nil
+		},
+	}
+	router := setupIdempotencyRouter(NewIdempotencyHandler(idempotencyService), NewSaleHandler(saleService))
+
+	req, _ := http.NewRequest("POST", "/sales", bytes.NewBufferString(idempotentSaleBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", "retry-1")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	if storedStatus != http.StatusCreated || !bytes.Equal(storedBody, w.Body.Bytes()) {
+		t.Errorf("Expected the response to be stored, got %d %s", storedStatus, storedBody)
+	}
+}
+
+func TestIdempotent_ReplaysStoredResponse(t *testing.T) {
+	status := http.StatusCreated
+	idempotencyService := &service.MockIdempotencyService{
+		BeginFunc: func(ctx context.Context, userID uuid.UUID, key, requestHash string) (*models.IdempotencyKey, error) {
+			return &models.IdempotencyKey{StatusCode: &status, Response: []byte(`{"success":true}`)}, nil
+		},
+	}
+	saleService := &service.MockSaleService{
+		CreateSaleFunc: func(ctx context.Context, req *models.CreateSalesRequest) (*models.Sale, error) {
+			t.Error("Expected the sale not to be created again")
+			return &models.Sale{}, nil
+		},
+	}
+	router := setupIdempotencyRouter(NewIdempotencyHandler(idempotencyService), NewSaleHandler(saleService))
+
+	req, _ := http.NewRequest("POST", "/sales", bytes.NewBufferString(idempotentSaleBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", "retry-1")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated || w.Body.String() != `{"success":true}` {
+		t.Errorf("Expected the stored response, got %d %s", w.Code, w.Body.String())
+	}
+
+	if w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("Expected the Idempotent-Replayed header")
+	}
+}
+
+func TestIdempotent_ReleasesKeyOnServerError(t *testing.T) {
+	released := false
+	idempotencyService := &service.MockIdempotencyService{
+		CompleteFunc: func(ctx context.Context, claim *models.IdempotencyKey, statusCode int, response []byte) error {
+			t.Error("Expected a server error not to be stored")
+			return nil
+		},
+		ReleaseFunc: func(ctx context.Context, claim *models.IdempotencyKey) error {
+			released = true
+			return nil
+		},
+	}
+	saleService := &service.MockSaleService{
+		CreateSaleFunc: func(ctx context.Context, req *models.CreateSalesRequest) (*models.Sale, error) {
+			return nil, errors.New("database unavailable")
+		},
+	}
+	router := setupIdempotencyRouter(NewIdempotencyHandler(idempotencyService), NewSaleHandler(saleService))
+
+	req, _ := http.NewRequest("POST", "/sales", bytes.NewBufferString(idempotentSaleBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", "retry-1")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+
+	if !released {
+		t.Error("Expected the key to be released")
+	}
+}
+
+func TestIdempotent_KeyErrors(t *testing.T) {
+	tests := map[error]int{
+		service.ErrIdempotencyKeyReused:     http.StatusUnprocessableEntity,
+		service.ErrIdempotencyKeyInProgress: http.StatusConflict,
+	}
+
+	for err, expected := range tests {
+		idempotencyService := &service.MockIdempotencyService{
+			BeginFunc: func(ctx context.Context, userID uuid.UUID, key, requestHash string) (*models.IdempotencyKey, error) {
+				return nil, err
+			},
+		}
+		router := setupIdempotencyRouter(NewIdempotencyHandler(idempotencyService), NewSaleHandler(&service.MockSaleService{}))
+
+		req, _ := http.NewRequest("POST", "/sales", bytes.NewBufferString(idempotentSaleBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", "retry-1")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != expected {
+			t.Errorf("%v: expected status %d, got %d", err, expected, w.Code)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"pencatatan/internal/metrics"
+	"pencatatan/internal/service"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MetricsHandler struct {
+	metrics *metrics.Metrics
+	token   string
+}
+
+// NewMetricsHandler creates a MetricsHandler. Scrapes must send token as a
+// bearer token.
+func NewMetricsHandler(metrics *metrics.Metrics, token string) *MetricsHandler {
+	return &MetricsHandler{
+		metrics: metrics,
+		token:   token,
+	}
+}
+
+// Observe records the duration and status of every request under its route
+// pattern, e.g. /api/sales/:id, so IDs do not each get their own series.
+// Requests that match no route are grouped as "unmatched".
+func (h *MetricsHandler) Observe(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+
+	h.metrics.ObserveRequest(c.Request.Method, route, strconv.Itoa(c.Writer.Status()), time.Since(start).Seconds())
+}
+
+// Serve answers Prometheus scrapes.
+func (h *MetricsHandler) Serve(c *gin.Context) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok {
+		c.Header("WWW-Authenticate", "Bearer")
+		_ = c.Error(errMissingToken)
+		return
+	}
+	// An empty token never matches, so a misconfigured handler serves nothing.
+	if h.token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+		_ = c.Error(service.ErrInvalidToken)
+		return
+	}
+
+	h.metrics.Handler().ServeHTTP(c.Writer, c.Request)
+}
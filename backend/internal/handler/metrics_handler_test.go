@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"pencatatan/internal/metrics"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func setupMetricsRouter(t *testing.T, token string) *gin.Engine {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to open sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	handler := NewMetricsHandler(metrics.New(db), token)
+
+	router := gin.New()
+	router.Use(handler.Observe, ErrorHandler)
+	router.GET("/metrics", handler.Serve)
+	router.GET("/sales/:id", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	return router
+}
+
+func TestMetrics_ObservesRoutes(t *testing.T) {
+	router := setupMetricsRouter(t, "scrape-secret")
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/sales/123", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/nowhere", nil))
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer scrape-secret")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	body := w.Body.String()
+	for _, want := range []string{`route="/sales/:id",status="204"`, `route="unmatched",status="404"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected a series with %s", want)
+		}
+	}
+	if strings.Contains(body, "/sales/123") {
+		t.Error("Expected IDs to be left out of route labels")
+	}
+}
+
+func TestMetrics_RequiresToken(t *testing.T) {
+	router := setupMetricsRouter(t, "scrape-secret")
+
+	tests := []struct {
+		name   string
+		header string
+		status int
+	}{
+		{"missing", "", http.StatusUnauthorized},
+		{"wrong", "Bearer nope", http.StatusUnauthorized},
+		{"valid", "Bearer scrape-secret", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/metrics", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, w.Code)
+			}
+		})
+	}
+}
+
+func TestMetrics_WithoutTokenServesNothing(t *testing.T) {
+	router := setupMetricsRouter(t, "")
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
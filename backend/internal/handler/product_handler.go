@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"net/http"
+	"pencatatan/internal/models"
+	"pencatatan/internal/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ProductHandler struct {
+	service service.ProductService
+}
+
+func NewProductHandler(service service.ProductService) *ProductHandler {
+	return &ProductHandler{
+		service: service,
+	}
+}
+
+func (h *ProductHandler) CreateProduct(c *gin.Context) {
+	var req models.CreateProductRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	product, err := h.service.CreateProduct(c.Request.Context(), &req)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, Response{
+		Success: true,
+		Message: "Product created successfully",
+		Data:    product,
+	})
+}
+
+func (h *ProductHandler) GetProductByID(c *gin.Context) {
+	id := c.Param("id")
+
+	product, err := h.service.GetProductByID(c.Request.Context(), id)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    product,
+	})
+}
+
+func (h *ProductHandler) GetAllProducts(c *gin.Context) {
+	activeOnly, _ := strconv.ParseBool(c.Query("active"))
+
+	products, err := h.service.GetAllProducts(c.Request.Context(), activeOnly)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    products,
+	})
+}
+
+func (h *ProductHandler) GetPriceList(c *gin.Context) {
+	items, err := h.service.GetPriceList(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    items,
+	})
+}
+
+func (h *ProductHandler) UpdateProduct(c *gin.Context) {
+	id := c.Param("id")
+	var req models.UpdateProductRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	product, err := h.service.UpdateProduct(c.Request.Context(), id, &req)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Message: "Product updated successfully",
+		Data:    product,
+	})
+}
+
+func (h *ProductHandler) DeleteProduct(c *gin.Context) {
+	id := c.Param("id")
+
+	err := h.service.DeleteProduct(c.Request.Context(), id)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Message: "Product deleted successfully",
+	})
+}
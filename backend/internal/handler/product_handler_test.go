@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"pencatatan/internal/models"
+	"pencatatan/internal/service"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func setupProductRouter(handler *ProductHandler) *gin.Engine {
+	router := gin.New()
+	router.Use(ErrorHandler)
+	router.POST("/products", handler.CreateProduct)
+	router.GET("/products", handler.GetAllProducts)
+	router.GET("/products/price-list", handler.GetPriceList)
+	router.GET("/products/:id", handler.GetProductByID)
+	router.PUT("/products/:id", handler.UpdateProduct)
+	router.DELETE("/products/:id", handler.DeleteProduct)
+	return router
+}
+
+func TestCreateProduct_Success(t *testing.T) {
+	mockService := &service.MockProductService{
+		CreateProductFunc: func(ctx context.Context, req *models.CreateProductRequest) (*models.Product, error) {
+			return &models.Product{ID: uuid.New(), SKU: req.SKU, Name: req.Name, Price: req.Price}, nil
+		},
+	}
+
+	router := setupProductRouter(NewProductHandler(mockService))
+
+	jsonBody := []byte(`{"sku":"KP-001","name":"Kopi","price":5000}`)
+	req, _ := http.NewRequest("POST", "/products", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+}
+
+func TestCreateProduct_Conflict(t *testing.T) {
+	mockService := &service.MockProductService{
+		CreateProductFunc: func(ctx context.Context, req *models.CreateProductRequest) (*models.Product, error) {
+			return nil, service.ErrProductExists
+		},
+	}
+
+	router := setupProductRouter(NewProductHandler(mockService))
+
+	jsonBody := []byte(`{"sku":"KP-001","name":"Kopi","price":5000}`)
+	req, _ := http.NewRequest("POST", "/products", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+}
+
+func TestCreateProduct_MissingPrice(t *testing.T) {
+	router := setupProductRouter(NewProductHandler(&service.MockProductService{}))
+
+	jsonBody := []byte(`{"sku":"KP-001","name":"Kopi"}`)
+	req, _ := http.NewRequest("POST", "/products", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestGetPriceList_Success(t *testing.T) {
+	mockService := &service.MockProductService{
+		GetPriceListFunc: func(ctx context.Context) ([]*models.PriceListItem, error) {
+			return []*models.PriceListItem{{ID: uuid.New(), Name: "Kopi", Price: models.NewMoney(5000)}}, nil
+		},
+	}
+
+	router := setupProductRouter(NewProductHandler(mockService))
+
+	req, _ := http.NewRequest("GET", "/products/price-list", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response Response
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	if !response.Success {
+		t.Error("Expected success to be true")
+	}
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"net/http"
+	"pencatatan/internal/models"
+	"pencatatan/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReportHandler struct {
+	service service.ReportService
+}
+
+func NewReportHandler(service service.ReportService) *ReportHandler {
+	return &ReportHandler{
+		service: service,
+	}
+}
+
+func (h *ReportHandler) DebtAging(c *gin.Context) {
+	report, err := h.service.DebtAging(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    report,
+	})
+}
+
+func (h *ReportHandler) SalesSummary(c *gin.Context) {
+	var params models.SalesSummaryParams
+
+	if err := c.ShouldBindQuery(&params); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	summary, err := h.service.SalesSummary(c.Request.Context(), &params)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    summary,
+	})
+}
@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"pencatatan/internal/models"
+	"pencatatan/internal/service"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func setupReportRouter(handler *ReportHandler) *gin.Engine {
+	router := gin.New()
+	router.Use(ErrorHandler)
+	router.GET("/reports/debt-aging", handler.DebtAging)
+	router.GET("/reports/summary", handler.SalesSummary)
+	return router
+}
+
+func TestDebtAging_Success(t *testing.T) {
+	mockService := &service.MockReportService{
+		DebtAgingFunc: func(ctx context.Context) (*models.DebtAgingReport, error) {
+			return &models.DebtAgingReport{
+				Customers: []*models.DebtAgingRow{
+					{Name: "Pak Budi", OpenSales: 1, DebtAgingBuckets: models.DebtAgingBuckets{
+						Over60Days: models.NewMoney(50000),
+						Total:      models.NewMoney(50000),
+					}},
+				},
+				Totals: models.DebtAgingBuckets{
+					Over60Days: models.NewMoney(50000),
+					Total:      models.NewMoney(50000),
+				},
+			}, nil
+		},
+	}
+
+	router := setupReportRouter(NewReportHandler(mockService))
+
+	req, _ := http.NewRequest("GET", "/reports/debt-aging", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var body struct {
+		Data struct {
+			Totals map[string]json.Number `json:"totals"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if body.Data.Totals["days_over_60"] != "50000" && body.Data.Totals["days_over_60"] != "50000.00" {
+		t.Errorf("Expected days_over_60 total of 50000, got %s", body.Data.Totals["days_over_60"])
+	}
+}
+
+func TestDebtAging_ServiceError(t *testing.T) {
+	mockService := &service.MockReportService{
+		DebtAgingFunc: func(ctx context.Context) (*models.DebtAgingReport, error) {
+			return nil, errors.New("db down")
+		},
+	}
+
+	router := setupReportRouter(NewReportHandler(mockService))
+
+	req, _ := http.NewRequest("GET", "/reports/debt-aging", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+}
+
+func TestSalesSummary_Success(t *testing.T) {
+	mockService := &service.MockReportService{
+		SalesSummaryFunc: func(ctx context.Context, params *models.SalesSummaryParams) (*models.SalesSummary, error) {
+			if params.Granularity != "week" || params.From != "2026-09-01" {
+				t.Errorf("Unexpected params %+v", params)
+			}
+			return &models.SalesSummary{Granularity: params.Granularity, Buckets: []*models.SalesSummaryBucket{}}, nil
+		},
+	}
+
+	router := setupReportRouter(NewReportHandler(mockService))
+
+	req, _ := http.NewRequest("GET", "/reports/summary?granularity=week&from=2026-09-01", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestSalesSummary_InvalidGranularity(t *testing.T) {
+	router := setupReportRouter(NewReportHandler(&service.MockReportService{}))
+
+	req, _ := http.NewRequest("GET", "/reports/summary?granularity=hour", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestSalesSummary_InvalidRange(t *testing.T) {
+	mockService := &service.MockReportService{
+		SalesSummaryFunc: func(ctx context.Context, params *models.SalesSummaryParams) (*models.SalesSummary, error) {
+			return nil, service.ErrInvalidDateRange
+		},
+	}
+
+	router := setupReportRouter(NewReportHandler(mockService))
+
+	req, _ := http.NewRequest("GET", "/reports/summary?from=2026-10-05&to=2026-10-01", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"pencatatan/internal/logging"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxRequestIDLength caps request IDs accepted from clients and proxies.
+const maxRequestIDLength = 128
+
+// RequestID tags the request with the X-Request-ID sent by the client or a
+// proxy in front of the API, or with a new one, and returns it in the
+// response. Log lines written with the request's context carry the ID.
+func RequestID(c *gin.Context) {
+	id := c.GetHeader("X-Request-ID")
+	if !validRequestID(id) {
+		id = uuid.NewString()
+	}
+
+	c.Header("X-Request-ID", id)
+	c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+	c.Next()
+}
+
+// validRequestID accepts IDs of printable ASCII, so a client cannot inject
+// line breaks or control characters into the logs.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// RequestLogger logs every request once it is answered. Server errors are
+// logged at error level and client errors at warn level.
+func RequestLogger(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+
+	status := c.Writer.Status()
+	level := slog.LevelInfo
+	switch {
+	case status >= http.StatusInternalServerError:
+		level = slog.LevelError
+	case status >= http.StatusBadRequest:
+		level = slog.LevelWarn
+	}
+
+	slog.Log(c.Request.Context(), level, "request",
+		"method", c.Request.Method,
+		"path", c.Request.URL.Path,
+		"route", c.FullPath(),
+		"status", status,
+		"duration", time.Since(start),
+		"bytes", c.Writer.Size(),
+		"client_ip", c.ClientIP(),
+	)
+}
+
+// Recovery answers a request whose handler panicked with a 500 and logs the
+// panic with its stack.
+func Recovery(c *gin.Context) {
+	defer func() {
+		if p := recover(); p != nil {
+			slog.ErrorContext(c.Request.Context(), "panic while handling request",
+				"error", fmt.Sprint(p),
+				"stack", string(debug.Stack()),
+			)
+			if !c.Writer.Written() {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, Response{
+					Success: false,
+					Error:   "internal server error",
+					Code:    codeInternal,
+				})
+				return
+			}
+			c.Abort()
+		}
+	}()
+
+	c.Next()
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"pencatatan/internal/logging"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func setupRequestRouter() *gin.Engine {
+	router := gin.New()
+	router.Use(RequestID, RequestLogger, Recovery, ErrorHandler)
+	router.GET("/ping", func(c *gin.Context) {
+		c.String(http.StatusOK, logging.RequestID(c.Request.Context()))
+	})
+	router.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+	return router
+}
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		keep   bool
+	}{
+		{"generated", "", false},
+		{"from client", "abc-123", true},
+		{"control characters", "abc\n123", false},
+		{"too long", strings.Repeat("a", maxRequestIDLength+1), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/ping", nil)
+			if tt.header != "" {
+				req.Header.Set("X-Request-ID", tt.header)
+			}
+			w := httptest.NewRecorder()
+			setupRequestRouter().ServeHTTP(w, req)
+
+			id := w.Header().Get("X-Request-ID")
+			if id == "" || w.Body.String() != id {
+				t.Fatalf("Expected the request ID in the header and context, got %q and %q", id, w.Body.String())
+			}
+			if (id == tt.header) != tt.keep {
+				t.Errorf("Expected keep=%t for %q, got %q", tt.keep, tt.header, id)
+			}
+		})
+	}
+}
+
+func TestRequestLogger_IncludesRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(logging.New(&buf, "json", slog.LevelInfo))
+	defer slog.SetDefault(previous)
+
+	req, _ := http.NewRequest("GET", "/ping", nil)
+	req.Header.Set("X-Request-ID", "req-42")
+	setupRequestRouter().ServeHTTP(httptest.NewRecorder(), req)
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("Expected one JSON log line, got %q", buf.String())
+	}
+
+	if line["request_id"] != "req-42" || line["route"] != "/ping" || line["status"] != float64(200) {
+		t.Errorf("Expected the request to be logged with its ID, got %v", line)
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	previous := slog.Default()
+	slog.SetDefault(logging.New(&bytes.Buffer{}, "text", slog.LevelInfo))
+	defer slog.SetDefault(previous)
+
+	req, _ := http.NewRequest("GET", "/panic", nil)
+	w := httptest.NewRecorder()
+	setupRequestRouter().ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+
+	var response Response
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	if response.Code != codeInternal {
+		t.Errorf("Expected code %q, got %q", codeInternal, response.Code)
+	}
+}
@@ -0,0 +1,250 @@
+package handler
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"pencatatan/internal/models"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	csvContentType  = "text/csv; charset=utf-8"
+	xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// saleExportHeaders are the column titles of a sales export. They are in
+// Indonesian because the file goes straight into the bookkeeper's spreadsheet.
+var saleExportHeaders = []string{
+	"Tanggal",
+	"No. Transaksi",
+	"Pelanggan",
+	"Produk",
+	"Jumlah Barang",
+	"Total",
+	"Dibayar",
+	"Kembalian",
+	"Status",
+	"Mata Uang",
+}
+
+// saleExportWriter writes sales to an export file one row at a time.
+type saleExportWriter interface {
+	Write(row *models.SaleExportRow) error
+	Close() error
+}
+
+func newSaleExportWriter(format string, w io.Writer) (saleExportWriter, error) {
+	if format == "xlsx" {
+		return newXLSXSaleWriter(w)
+	}
+	return newCSVSaleWriter(w)
+}
+
+func saleExportContentType(format string) string {
+	if format == "xlsx" {
+		return xlsxContentType
+	}
+	return csvContentType
+}
+
+// saleExportFilename names the file after the exported range, e.g.
+// penjualan_2026-10-01_2026-10-31.xlsx.
+func saleExportFilename(params *models.SaleExportParams) string {
+	name := "penjualan"
+	if params.From != "" {
+		name += "_" + params.From
+	}
+	if params.To != "" {
+		name += "_" + params.To
+	}
+	name = strings.Map(func(r rune) rune {
+		if r == '"' || r == '/' || r == '\\' || r == ':' {
+			return '-'
+		}
+		return r
+	}, name)
+	return name + "." + params.Format
+}
+
+func debtStatus(isDebt bool) string {
+	if isDebt {
+		return "Hutang"
+	}
+	return "Lunas"
+}
+
+// csvSaleWriter writes a semicolon separated file with decimal commas, which
+// is what spreadsheet programs expect with Indonesian regional settings.
+type csvSaleWriter struct {
+	w *csv.Writer
+}
+
+func newCSVSaleWriter(w io.Writer) (*csvSaleWriter, error) {
+	// The byte order mark makes Excel read the file as UTF-8.
+	if _, err := io.WriteString(w, "\uFEFF"); err != nil {
+		return nil, err
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Comma = ';'
+	if err := cw.Write(saleExportHeaders); err != nil {
+		return nil, err
+	}
+
+	return &csvSaleWriter{w: cw}, nil
+}
+
+// csvText keeps a spreadsheet from running a customer or product name that
+// starts like a formula, such as =HYPERLINK(...), by prefixing it with a quote.
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func csvMoney(m models.Money) string {
+	return strings.Replace(m.String(), ".", ",", 1)
+}
+
+func (w *csvSaleWriter) Write(row *models.SaleExportRow) error {
+	return w.w.Write([]string{
+		row.TransactionDate.Format("02/01/2006 15:04"),
+		row.ID.String(),
+		csvText(row.Name),
+		csvText(row.Products),
+		fmt.Sprint(row.ItemsSold),
+		csvMoney(row.Total),
+		csvMoney(row.AmountReceived),
+		csvMoney(row.ChangeAmount),
+		debtStatus(row.IsDebt),
+		row.Currency,
+	})
+}
+
+func (w *csvSaleWriter) Close() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+// xlsxSaleWriter uses the excelize stream writer, which keeps only a small
+// window of rows in memory and spills the rest to a temporary file.
+type xlsxSaleWriter struct {
+	out        io.Writer
+	file       *excelize.File
+	stream     *excelize.StreamWriter
+	row        int
+	moneyStyle int
+	dateStyle  int
+	textStyle  int
+}
+
+func newXLSXSaleWriter(out io.Writer) (*xlsxSaleWriter, error) {
+	const sheet = "Penjualan"
+
+	file := excelize.NewFile()
+	if err := file.SetSheetName("Sheet1", sheet); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	w := &xlsxSaleWriter{out: out, file: file, row: 1}
+	if err := w.init(sheet); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (w *xlsxSaleWriter) init(sheet string) error {
+	var err error
+	if w.stream, err = w.file.NewStreamWriter(sheet); err != nil {
+		return err
+	}
+
+	moneyFormat := `"Rp"#,##0.00`
+	if w.moneyStyle, err = w.file.NewStyle(&excelize.Style{CustomNumFmt: &moneyFormat}); err != nil {
+		return err
+	}
+
+	dateFormat := "dd/mm/yyyy hh:mm"
+	if w.dateStyle, err = w.file.NewStyle(&excelize.Style{CustomNumFmt: &dateFormat}); err != nil {
+		return err
+	}
+
+	// Number format 49 is "@", text: the cell is never read as a formula.
+	if w.textStyle, err = w.file.NewStyle(&excelize.Style{NumFmt: 49}); err != nil {
+		return err
+	}
+
+	headerStyle, err := w.file.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+
+	widths := []float64{18, 38, 24, 40, 14, 18, 18, 18, 10, 10}
+	for i, width := range widths {
+		if err := w.stream.SetColWidth(i+1, i+1, width); err != nil {
+			return err
+		}
+	}
+
+	header := make([]interface{}, len(saleExportHeaders))
+	for i, title := range saleExportHeaders {
+		header[i] = excelize.Cell{StyleID: headerStyle, Value: title}
+	}
+
+	return w.next(header)
+}
+
+func (w *xlsxSaleWriter) next(values []interface{}) error {
+	cell, err := excelize.CoordinatesToCellName(1, w.row)
+	if err != nil {
+		return err
+	}
+	w.row++
+	return w.stream.SetRow(cell, values)
+}
+
+func (w *xlsxSaleWriter) money(m models.Money) excelize.Cell {
+	return excelize.Cell{StyleID: w.moneyStyle, Value: m.Float64()}
+}
+
+func (w *xlsxSaleWriter) text(s string) excelize.Cell {
+	return excelize.Cell{StyleID: w.textStyle, Value: s}
+}
+
+func (w *xlsxSaleWriter) Write(row *models.SaleExportRow) error {
+	// Excel has no time zones, so the local wall clock time is stored as is.
+	t := row.TransactionDate
+	wallClock := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+
+	return w.next([]interface{}{
+		excelize.Cell{StyleID: w.dateStyle, Value: wallClock},
+		row.ID.String(),
+		w.text(row.Name),
+		w.text(row.Products),
+		row.ItemsSold,
+		w.money(row.Total),
+		w.money(row.AmountReceived),
+		w.money(row.ChangeAmount),
+		debtStatus(row.IsDebt),
+		row.Currency,
+	})
+}
+
+func (w *xlsxSaleWriter) Close() error {
+	defer w.file.Close()
+
+	if err := w.stream.Flush(); err != nil {
+		return err
+	}
+
+	_, err := w.file.WriteTo(w.out)
+	return err
+}
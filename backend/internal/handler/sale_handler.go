@@ -1,9 +1,13 @@
 package handler
 
 import (
+	"fmt"
+	"io"
 	"net/http"
 	"pencatatan/internal/models"
 	"pencatatan/internal/service"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -18,30 +22,81 @@ func NewSaleHandler(service service.SaleService) *SaleHandler {
 	}
 }
 
+// Response is the body of every JSON response. Failed requests set Error to
+// a message for people and Code to a stable identifier for programs, plus
+// Details when particular fields are invalid.
 type Response struct {
-	Success bool        `json:"success"`
-	Message string      `json:"message,omitempty"`
-	Data    interface{} `json:"data,omitempty"`
-	Error   string      `json:"error,omitempty"`
+	Success bool         `json:"success"`
+	Message string       `json:"message,omitempty"`
+	Data    interface{}  `json:"data,omitempty"`
+	Meta    interface{}  `json:"meta,omitempty"`
+	Error   string       `json:"error,omitempty"`
+	Code    string       `json:"code,omitempty"`
+	Details []FieldError `json:"details,omitempty"`
+}
+
+// errIfMatchRequired rejects sale updates sent without the version they are
+// based on.
+var errIfMatchRequired = &service.Error{
+	Kind:    service.KindPreconditionRequired,
+	Code:    "if_match_required",
+	Message: "If-Match header with the sale's ETag is required",
+}
+
+// errTransactionDateForbidden rejects a transaction_date from users who may
+// only change today's sales: with it they could back-date a sale, or date it
+// in the future to keep it editable.
+var errTransactionDateForbidden = &service.Error{
+	Kind:    service.KindForbidden,
+	Code:    "transaction_date_forbidden",
+	Message: "only users who manage sales may set transaction_date",
+}
+
+// saleETag returns the entity tag of the sale's current version.
+func saleETag(sale *models.Sale) string {
+	return `"` + strconv.Itoa(sale.Version) + `"`
+}
+
+// ifMatchVersion returns the sale version named by an If-Match header,
+// models.AnyVersion for *, which matches any existing sale, or 0, which no
+// sale has, when the header holds anything else. Weak tags are accepted
+// because proxies that compress responses weaken the ETag.
+func ifMatchVersion(header string) int {
+	header = strings.TrimSpace(header)
+	if header == "*" {
+		return models.AnyVersion
+	}
+
+	tag := strings.TrimPrefix(header, "W/")
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0
+	}
+
+	version, err := strconv.Atoi(tag[1 : len(tag)-1])
+	if err != nil || version < 1 {
+		return 0
+	}
+	return version
 }
 
 func (h *SaleHandler) CreateSale(c *gin.Context) {
 	var req models.CreateSalesRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, Response{
-			Success: false,
-			Error:   err.Error(),
-		})
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	if user := currentUser(c); req.TransactionDate != nil && (user == nil || !user.Can(models.PermissionManageSales)) {
+		_ = c.Error(errTransactionDateForbidden)
 		return
 	}
 
-	sale, err := h.service.CreateSale(&req)
+	req.CreatedBy = currentUserID(c)
+
+	sale, err := h.service.CreateSale(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, Response{
-			Success: false,
-			Error:   err.Error(),
-		})
+		_ = c.Error(err)
 		return
 	}
 
@@ -55,15 +110,13 @@ func (h *SaleHandler) CreateSale(c *gin.Context) {
 func (h *SaleHandler) GetSaleByID(c *gin.Context) {
 	id := c.Param("id")
 
-	sale, err := h.service.GetSaleByID(id)
+	sale, err := h.service.GetSaleByID(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, Response{
-			Success: false,
-			Error:   err.Error(),
-		})
+		_ = c.Error(err)
 		return
 	}
 
+	c.Header("ETag", saleETag(sale))
 	c.JSON(http.StatusOK, Response{
 		Success: true,
 		Data:    sale,
@@ -71,42 +124,178 @@ func (h *SaleHandler) GetSaleByID(c *gin.Context) {
 }
 
 func (h *SaleHandler) GetAllSales(c *gin.Context) {
-	sales, err := h.service.GetAllSales()
+	var params models.SaleListParams
+
+	if err := c.ShouldBindQuery(&params); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	page, err := h.service.GetAllSales(c.Request.Context(), &params)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    page.Sales,
+		Meta:    page.Meta,
+	})
+}
+
+func (h *SaleHandler) SearchSales(c *gin.Context) {
+	var params models.SaleSearchParams
+
+	if err := c.ShouldBindQuery(&params); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	results, err := h.service.SearchSales(c.Request.Context(), &params)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, Response{
-			Success: false,
-			Error:   err.Error(),
-		})
+		_ = c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, Response{
 		Success: true,
-		Data:    sales,
+		Data:    results,
+	})
+}
+
+// ExportSales streams the sales of a date range as a CSV or XLSX download.
+// The file is only started once the range is known to be valid, so request
+// errors are still reported as JSON.
+func (h *SaleHandler) ExportSales(c *gin.Context) {
+	var params models.SaleExportParams
+
+	if err := c.ShouldBindQuery(&params); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	if params.Format == "" {
+		params.Format = "csv"
+	}
+
+	var writer saleExportWriter
+	open := func() error {
+		if writer != nil {
+			return nil
+		}
+		c.Header("Content-Type", saleExportContentType(params.Format))
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, saleExportFilename(&params)))
+
+		var err error
+		writer, err = newSaleExportWriter(params.Format, c.Writer)
+		return err
+	}
+
+	err := h.service.ExportSales(c.Request.Context(), &params, func(row *models.SaleExportRow) error {
+		if err := open(); err != nil {
+			return err
+		}
+		return writer.Write(row)
 	})
+	if err == nil {
+		err = open()
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+
+	if err != nil {
+		if c.Writer.Written() {
+			// Part of the file is already sent; all that is left is to cut
+			// the download short.
+			_ = c.Error(err)
+			c.Abort()
+			return
+		}
+
+		c.Writer.Header().Del("Content-Type")
+		c.Writer.Header().Del("Content-Disposition")
+		_ = c.Error(err)
+	}
 }
 
+// maxImportSize caps the size of an uploaded CSV file.
+const maxImportSize = 32 << 20
+
+// ImportSales loads sales from a CSV file sent either as the "file" field of a
+// multipart form or as the raw request body. Rows with problems are listed in
+// the report instead of failing the whole request.
+func (h *SaleHandler) ImportSales(c *gin.Context) {
+	var params models.SaleImportParams
+
+	if err := c.ShouldBindQuery(&params); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+
+	var file io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			_ = c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+
+		f, err := header.Open()
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		defer f.Close()
+		file = f
+	}
+
+	report, err := h.service.ImportSales(c.Request.Context(), file, params.DryRun, currentUserID(c))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	message := "Import finished"
+	if report.DryRun {
+		message = "Dry run finished, nothing was saved"
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Message: message,
+		Data:    report,
+	})
+}
+
+// UpdateSale requires the ETag from GetSaleByID in If-Match, so a change made
+// by someone else in between is reported with 412 instead of overwritten.
 func (h *SaleHandler) UpdateSale(c *gin.Context) {
 	id := c.Param("id")
 	var req models.UpdateSaleRequest
 
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" {
+		_ = c.Error(errIfMatchRequired)
+		return
+	}
+
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, Response{
-			Success: false,
-			Error:   err.Error(),
-		})
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
 		return
 	}
+	req.Version = ifMatchVersion(ifMatch)
 
-	sale, err := h.service.UpdateSales(id, &req)
+	sale, err := h.service.UpdateSales(c.Request.Context(), currentUser(c), id, &req)
 	if err != nil {
-		c.JSON(http.StatusNotFound, Response{
-			Success: false,
-			Error:   err.Error(),
-		})
+		_ = c.Error(err)
 		return
 	}
 
+	c.Header("ETag", saleETag(sale))
 	c.JSON(http.StatusOK, Response{
 		Success: true,
 		Message: "Sale updated successfully",
@@ -117,12 +306,9 @@ func (h *SaleHandler) UpdateSale(c *gin.Context) {
 func (h *SaleHandler) DeleteSale(c *gin.Context) {
 	id := c.Param("id")
 
-	err := h.service.DeleteSales(id)
+	err := h.service.DeleteSales(c.Request.Context(), currentUser(c), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, Response{
-			Success: false,
-			Error:   err.Error(),
-		})
+		_ = c.Error(err)
 		return
 	}
 
@@ -131,3 +317,59 @@ func (h *SaleHandler) DeleteSale(c *gin.Context) {
 		Message: "Sale deleted successfully",
 	})
 }
+
+// GetSaleHistory lists who created, changed or deleted a sale, with the sale
+// before and after each change.
+func (h *SaleHandler) GetSaleHistory(c *gin.Context) {
+	id := c.Param("id")
+
+	entries, err := h.service.GetSaleHistory(c.Request.Context(), id)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    entries,
+	})
+}
+
+// GetTrash lists deleted sales that can still be restored. It takes the same
+// query parameters as GetAllSales.
+func (h *SaleHandler) GetTrash(c *gin.Context) {
+	var params models.SaleListParams
+
+	if err := c.ShouldBindQuery(&params); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	page, err := h.service.GetTrash(c.Request.Context(), &params)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    page.Sales,
+		Meta:    page.Meta,
+	})
+}
+
+func (h *SaleHandler) RestoreSale(c *gin.Context) {
+	id := c.Param("id")
+
+	sale, err := h.service.RestoreSale(c.Request.Context(), currentUser(c), id)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Message: "Sale restored successfully",
+		Data:    sale,
+	})
+}
@@ -2,17 +2,22 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"pencatatan/internal/models"
 	"pencatatan/internal/service"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/xuri/excelize/v2"
 )
 
 func init() {
@@ -21,23 +26,29 @@ func init() {
 
 func setupRouter(handler *SaleHandler) *gin.Engine {
 	router := gin.New()
+	router.Use(ErrorHandler)
 	router.POST("/sales", handler.CreateSale)
 	router.GET("/sales", handler.GetAllSales)
+	router.GET("/sales/search", handler.SearchSales)
+	router.GET("/sales/trash", handler.GetTrash)
+	router.GET("/sales/export", handler.ExportSales)
+	router.POST("/sales/import", handler.ImportSales)
 	router.GET("/sales/:id", handler.GetSaleByID)
 	router.PUT("/sales/:id", handler.UpdateSale)
+	router.PATCH("/sales/:id", handler.UpdateSale)
 	router.DELETE("/sales/:id", handler.DeleteSale)
+	router.GET("/sales/:id/history", handler.GetSaleHistory)
+	router.POST("/sales/:id/restore", handler.RestoreSale)
 	return router
 }
 
 func TestCreateSale_Success(t *testing.T) {
 	mockService := &service.MockSaleService{
-		CreateSaleFunc: func(req *models.CreateSalesRequest) (*models.Sale, error) {
+		CreateSaleFunc: func(ctx context.Context, req *models.CreateSalesRequest) (*models.Sale, error) {
+			total, _ := models.ItemsTotal(req.Items)
 			return &models.Sale{
 				ID:             uuid.New(),
-				Product:        req.Product,
-				Quantity:       req.Quantity,
-				Price:          req.Price,
-				Total:          float64(req.Quantity) * req.Price,
+				Total:          total,
 				AmountReceived: req.AmountReceived,
 				IsDebt:         req.IsDebt,
 				CreatedAt:      time.Now(),
@@ -50,10 +61,10 @@ func TestCreateSale_Success(t *testing.T) {
 	router := setupRouter(handler)
 
 	reqBody := models.CreateSalesRequest{
-		Product:        "Test Product",
-		Quantity:       2,
-		Price:          10000,
-		AmountReceived: 25000,
+		Items: []models.SaleItemRequest{
+			{Product: "Test Product", Quantity: 2, Price: models.NewMoney(10000)},
+		},
+		AmountReceived: models.NewMoney(25000),
 		IsDebt:         false,
 	}
 
@@ -76,6 +87,46 @@ func TestCreateSale_Success(t *testing.T) {
 	}
 }
 
+func TestCreateSale_TransactionDate(t *testing.T) {
+	tests := []struct {
+		role   models.Role
+		status int
+	}{
+		{models.RoleCashier, http.StatusForbidden},
+		{models.RoleOwner, http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.role), func(t *testing.T) {
+			mockService := &service.MockSaleService{
+				CreateSaleFunc: func(ctx context.Context, req *models.CreateSalesRequest) (*models.Sale, error) {
+					return &models.Sale{ID: uuid.New(), TransactionDate: *req.TransactionDate}, nil
+				},
+			}
+			user := &models.User{ID: uuid.New(), Role: tt.role, IsActive: true}
+
+			router := gin.New()
+			router.Use(ErrorHandler)
+			router.POST("/sales", func(c *gin.Context) {
+				c.Set(userContextKey, user)
+			}, NewSaleHandler(mockService).CreateSale)
+
+			for _, date := range []string{"2020-01-01T10:00:00Z", "2099-01-01T10:00:00Z"} {
+				body := `{"items":[{"product":"Beras","quantity":1,"price":12000}],"amount_received":12000,"transaction_date":"` + date + `"}`
+				req, _ := http.NewRequest("POST", "/sales", bytes.NewBufferString(body))
+				req.Header.Set("Content-Type", "application/json")
+
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+
+				if w.Code != tt.status {
+					t.Errorf("Expected status %d for %s, got %d: %s", tt.status, date, w.Code, w.Body.String())
+				}
+			}
+		})
+	}
+}
+
 func TestCreateSale_BadRequest(t *testing.T) {
 	mockService := &service.MockSaleService{}
 	handler := NewSaleHandler(mockService)
@@ -99,9 +150,33 @@ func TestCreateSale_BadRequest(t *testing.T) {
 	}
 }
 
+func TestCreateSale_InvalidItems(t *testing.T) {
+	router := setupRouter(NewSaleHandler(&service.MockSaleService{}))
+
+	bodies := []string{
+		`{"amount_received": 1000}`,
+		`{"items": []}`,
+		`{"items": [{"product": "Beras", "quantity": 0, "price": 1000}]}`,
+		`{"items": [{"product": "Beras", "quantity": 2147483648, "price": 1000}]}`,
+		`{"items": [{"product": "Beras", "quantity": 1, "price": 100000000}]}`,
+	}
+
+	for _, body := range bodies {
+		req, _ := http.NewRequest("POST", "/sales", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, body, w.Code)
+		}
+	}
+}
+
 func TestCreateSale_ServiceError(t *testing.T) {
 	mockService := &service.MockSaleService{
-		CreateSaleFunc: func(req *models.CreateSalesRequest) (*models.Sale, error) {
+		CreateSaleFunc: func(ctx context.Context, req *models.CreateSalesRequest) (*models.Sale, error) {
 			return nil, errors.New("service error")
 		},
 	}
@@ -110,10 +185,10 @@ func TestCreateSale_ServiceError(t *testing.T) {
 	router := setupRouter(handler)
 
 	reqBody := models.CreateSalesRequest{
-		Product:        "Test Product",
-		Quantity:       2,
-		Price:          10000,
-		AmountReceived: 5000, // Insufficient amount
+		Items: []models.SaleItemRequest{
+			{Product: "Test Product", Quantity: 2, Price: models.NewMoney(10000)},
+		},
+		AmountReceived: models.NewMoney(5000), // Insufficient amount
 		IsDebt:         false,
 	}
 
@@ -132,12 +207,12 @@ func TestCreateSale_ServiceError(t *testing.T) {
 func TestGetSaleByID_Success(t *testing.T) {
 	expectedID := uuid.New()
 	mockService := &service.MockSaleService{
-		GetSaleByIDFunc: func(id string) (*models.Sale, error) {
+		GetSaleByIDFunc: func(ctx context.Context, id string) (*models.Sale, error) {
 			return &models.Sale{
-				ID:       expectedID,
-				Product:  "Test Product",
-				Quantity: 1,
-				Price:    5000,
+				ID:      expectedID,
+				Items:   []*models.SaleItem{{Product: "Test Product", Quantity: 1, Price: models.NewMoney(5000)}},
+				Total:   models.NewMoney(5000),
+				Version: 2,
 			}, nil
 		},
 	}
@@ -159,12 +234,39 @@ func TestGetSaleByID_Success(t *testing.T) {
 	if !response.Success {
 		t.Error("Expected success to be true")
 	}
+
+	if etag := w.Header().Get("ETag"); etag != `"2"` {
+		t.Errorf("Expected ETag %q, got %q", `"2"`, etag)
+	}
+}
+
+func TestGetSaleByID_PassesRequestContext(t *testing.T) {
+	type ctxKey struct{}
+	var got interface{}
+	mockService := &service.MockSaleService{
+		GetSaleByIDFunc: func(ctx context.Context, id string) (*models.Sale, error) {
+			got = ctx.Value(ctxKey{})
+			return &models.Sale{ID: uuid.New(), Version: 1}, nil
+		},
+	}
+
+	handler := NewSaleHandler(mockService)
+	router := setupRouter(handler)
+
+	req, _ := http.NewRequest("GET", "/sales/"+uuid.New().String(), nil)
+	req = req.WithContext(context.WithValue(req.Context(), ctxKey{}, "request"))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if got != "request" {
+		t.Errorf("Expected the service to get the request context, got value %v", got)
+	}
 }
 
 func TestGetSaleByID_NotFound(t *testing.T) {
 	mockService := &service.MockSaleService{
-		GetSaleByIDFunc: func(id string) (*models.Sale, error) {
-			return nil, errors.New("sale not found")
+		GetSaleByIDFunc: func(ctx context.Context, id string) (*models.Sale, error) {
+			return nil, service.ErrSaleNotFound
 		},
 	}
 
@@ -181,11 +283,16 @@ func TestGetSaleByID_NotFound(t *testing.T) {
 }
 
 func TestGetAllSales_Success(t *testing.T) {
+	var gotParams *models.SaleListParams
 	mockService := &service.MockSaleService{
-		GetAllSalesFunc: func() ([]*models.Sale, error) {
-			return []*models.Sale{
-				{ID: uuid.New(), Product: "Product 1"},
-				{ID: uuid.New(), Product: "Product 2"},
+		GetAllSalesFunc: func(ctx context.Context, params *models.SaleListParams) (*models.SalePage, error) {
+			gotParams = params
+			return &models.SalePage{
+				Sales: []*models.Sale{
+					{ID: uuid.New(), Total: models.NewMoney(1000)},
+					{ID: uuid.New(), Total: models.NewMoney(2000)},
+				},
+				Meta: models.PageMeta{Page: 1, Limit: 20, Total: 2, TotalPages: 1},
 			}, nil
 		},
 	}
@@ -193,7 +300,7 @@ func TestGetAllSales_Success(t *testing.T) {
 	handler := NewSaleHandler(mockService)
 	router := setupRouter(handler)
 
-	req, _ := http.NewRequest("GET", "/sales", nil)
+	req, _ := http.NewRequest("GET", "/sales?page=1&limit=20&is_debt=true&sort=total&order=asc", nil)
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
@@ -207,17 +314,346 @@ func TestGetAllSales_Success(t *testing.T) {
 	if !response.Success {
 		t.Error("Expected success to be true")
 	}
+
+	if response.Meta == nil {
+		t.Error("Expected pagination meta")
+	}
+
+	if gotParams.IsDebt == nil || !*gotParams.IsDebt || gotParams.Sort != "total" {
+		t.Errorf("Expected query params to be bound, got %+v", gotParams)
+	}
+}
+
+func TestGetAllSales_InvalidSort(t *testing.T) {
+	mockService := &service.MockSaleService{}
+	handler := NewSaleHandler(mockService)
+	router := setupRouter(handler)
+
+	req, _ := http.NewRequest("GET", "/sales?sort=password", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestSearchSales_Success(t *testing.T) {
+	mockService := &service.MockSaleService{
+		SearchSalesFunc: func(ctx context.Context, params *models.SaleSearchParams) ([]*models.SaleSearchResult, error) {
+			if params.Q != "kopi" {
+				t.Errorf("Expected query kopi, got %s", params.Q)
+			}
+			return []*models.SaleSearchResult{
+				{
+					Sale:      models.Sale{ID: uuid.New(), Items: []*models.SaleItem{{Product: "Kopi Susu", Quantity: 1}}},
+					Rank:      0.6,
+					Highlight: models.SaleHighlight{Products: "<mark>Kopi</mark> Susu"},
+				},
+			}, nil
+		},
+	}
+
+	handler := NewSaleHandler(mockService)
+	router := setupRouter(handler)
+
+	req, _ := http.NewRequest("GET", "/sales/search?q=kopi", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestSearchSales_MissingQuery(t *testing.T) {
+	mockService := &service.MockSaleService{}
+	handler := NewSaleHandler(mockService)
+	router := setupRouter(handler)
+
+	req, _ := http.NewRequest("GET", "/sales/search", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func exportRows(fn func(*models.SaleExportRow) error) error {
+	rows := []*models.SaleExportRow{
+		{
+			Sale: models.Sale{
+				ID:              uuid.New(),
+				Name:            "Bu Sari",
+				Total:           models.NewMoney(27500),
+				AmountReceived:  models.NewMoney(30000),
+				ChangeAmount:    models.NewMoney(2500),
+				Currency:        "IDR",
+				TransactionDate: time.Date(2026, 10, 1, 9, 30, 0, 0, time.UTC),
+			},
+			Products:  "Beras x2, Gula x1",
+			ItemsSold: 3,
+		},
+		{
+			Sale: models.Sale{
+				ID:              uuid.New(),
+				Name:            "Pak Budi",
+				Total:           models.NewMoney(12000),
+				Currency:        "IDR",
+				IsDebt:          true,
+				TransactionDate: time.Date(2026, 10, 2, 14, 0, 0, 0, time.UTC),
+			},
+			Products:  "Telur x6",
+			ItemsSold: 6,
+		},
+	}
+	for _, row := range rows {
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestExportSales_CSV(t *testing.T) {
+	mockService := &service.MockSaleService{
+		ExportSalesFunc: func(ctx context.Context, params *models.SaleExportParams, fn func(*models.SaleExportRow) error) error {
+			return exportRows(fn)
+		},
+	}
+
+	router := setupRouter(NewSaleHandler(mockService))
+
+	req, _ := http.NewRequest("GET", "/sales/export?from=2026-10-01&to=2026-10-31", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	if got := w.Header().Get("Content-Disposition"); !strings.Contains(got, "penjualan_2026-10-01_2026-10-31.csv") {
+		t.Errorf("Unexpected Content-Disposition %q", got)
+	}
+
+	lines := strings.Split(strings.TrimSpace(strings.TrimPrefix(w.Body.String(), "\uFEFF")), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected header and 2 rows, got %d lines", len(lines))
+	}
+
+	if !strings.HasPrefix(lines[0], "Tanggal;No. Transaksi;Pelanggan") {
+		t.Errorf("Unexpected header %q", lines[0])
+	}
+
+	if !strings.Contains(lines[1], ";27500,00;30000,00;2500,00;Lunas;IDR") {
+		t.Errorf("Unexpected row %q", lines[1])
+	}
+
+	if !strings.Contains(lines[2], ";Hutang;") {
+		t.Errorf("Expected debt status in row %q", lines[2])
+	}
+}
+
+func TestExportSales_XLSX(t *testing.T) {
+	mockService := &service.MockSaleService{
+		ExportSalesFunc: func(ctx context.Context, params *models.SaleExportParams, fn func(*models.SaleExportRow) error) error {
+			return exportRows(fn)
+		},
+	}
+
+	router := setupRouter(NewSaleHandler(mockService))
+
+	req, _ := http.NewRequest("GET", "/sales/export?format=xlsx", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	file, err := excelize.OpenReader(w.Body)
+	if err != nil {
+		t.Fatalf("Expected a valid workbook, got %v", err)
+	}
+	defer file.Close()
+
+	rows, err := file.GetRows("Penjualan")
+	if err != nil {
+		t.Fatalf("Expected sheet Penjualan, got %v", err)
+	}
+
+	if len(rows) != 3 {
+		t.Fatalf("Expected header and 2 rows, got %d", len(rows))
+	}
+
+	if rows[0][0] != "Tanggal" || rows[2][8] != "Hutang" {
+		t.Errorf("Unexpected rows %v", rows)
+	}
+
+	if total, _ := file.GetCellValue("Penjualan", "F2", excelize.Options{RawCellValue: true}); total != "27500" {
+		t.Errorf("Expected total stored as a number, got %q", total)
+	}
+}
+
+func TestExportSales_EscapesFormulas(t *testing.T) {
+	mockService := &service.MockSaleService{
+		ExportSalesFunc: func(ctx context.Context, params *models.SaleExportParams, fn func(*models.SaleExportRow) error) error {
+			return fn(&models.SaleExportRow{
+				Sale:     models.Sale{ID: uuid.New(), Name: `=HYPERLINK("http://evil.example","klik")`, Currency: "IDR"},
+				Products: "@SUM(1+1) x1",
+			})
+		},
+	}
+
+	router := setupRouter(NewSaleHandler(mockService))
+
+	req, _ := http.NewRequest("GET", "/sales/export", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if !strings.Contains(w.Body.String(), `;"'=HYPERLINK(""http://evil.example"",""klik"")";'@SUM(1+1) x1;`) {
+		t.Errorf("Expected formulas to be quoted in the CSV, got %q", w.Body.String())
+	}
+
+	req, _ = http.NewRequest("GET", "/sales/export?format=xlsx", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	file, err := excelize.OpenReader(w.Body)
+	if err != nil {
+		t.Fatalf("Expected a valid workbook, got %v", err)
+	}
+	defer file.Close()
+
+	for cell, want := range map[string]string{"C2": `=HYPERLINK("http://evil.example","klik")`, "D2": "@SUM(1+1) x1"} {
+		if formula, _ := file.GetCellFormula("Penjualan", cell); formula != "" {
+			t.Errorf("Expected %s to hold text, got formula %q", cell, formula)
+		}
+		if value, _ := file.GetCellValue("Penjualan", cell); value != want {
+			t.Errorf("Expected %s to be %q, got %q", cell, want, value)
+		}
+	}
+}
+
+func TestExportSales_InvalidRange(t *testing.T) {
+	mockService := &service.MockSaleService{
+		ExportSalesFunc: func(ctx context.Context, params *models.SaleExportParams, fn func(*models.SaleExportRow) error) error {
+			return service.ErrInvalidDateRange
+		},
+	}
+
+	router := setupRouter(NewSaleHandler(mockService))
+
+	req, _ := http.NewRequest("GET", "/sales/export?from=2026-10-31&to=2026-10-01", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	if w.Header().Get("Content-Disposition") != "" {
+		t.Error("Expected no attachment for an error response")
+	}
+}
+
+func TestExportSales_InvalidFormat(t *testing.T) {
+	router := setupRouter(NewSaleHandler(&service.MockSaleService{}))
+
+	req, _ := http.NewRequest("GET", "/sales/export?format=pdf", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestImportSales_Multipart(t *testing.T) {
+	mockService := &service.MockSaleService{
+		ImportSalesFunc: func(ctx context.Context, r io.Reader, dryRun bool, createdBy *uuid.UUID) (*models.SaleImportReport, error) {
+			content, _ := io.ReadAll(r)
+			if !strings.HasPrefix(string(content), "product,quantity") {
+				t.Errorf("Unexpected file content %q", content)
+			}
+			if !dryRun {
+				t.Error("Expected dry run")
+			}
+			return &models.SaleImportReport{DryRun: dryRun, Rows: 1, Sales: 1, Valid: 1}, nil
+		},
+	}
+
+	router := setupRouter(NewSaleHandler(mockService))
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "sales.csv")
+	part.Write([]byte("product,quantity,price\nBeras,1,12000\n"))
+	form.Close()
+
+	req, _ := http.NewRequest("POST", "/sales/import?dry_run=true", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestImportSales_RawBody(t *testing.T) {
+	mockService := &service.MockSaleService{
+		ImportSalesFunc: func(ctx context.Context, r io.Reader, dryRun bool, createdBy *uuid.UUID) (*models.SaleImportReport, error) {
+			return &models.SaleImportReport{Rows: 1, Sales: 1, Valid: 1, Imported: 1}, nil
+		},
+	}
+
+	router := setupRouter(NewSaleHandler(mockService))
+
+	req, _ := http.NewRequest("POST", "/sales/import", bytes.NewBufferString("product,quantity,price\nBeras,1,12000\n"))
+	req.Header.Set("Content-Type", "text/csv")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestImportSales_InvalidFile(t *testing.T) {
+	mockService := &service.MockSaleService{
+		ImportSalesFunc: func(ctx context.Context, r io.Reader, dryRun bool, createdBy *uuid.UUID) (*models.SaleImportReport, error) {
+			return nil, service.ErrInvalidImportFile
+		},
+	}
+
+	router := setupRouter(NewSaleHandler(mockService))
+
+	req, _ := http.NewRequest("POST", "/sales/import", bytes.NewBufferString(""))
+	req.Header.Set("Content-Type", "text/csv")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
 }
 
 func TestUpdateSale_Success(t *testing.T) {
 	expectedID := uuid.New()
+	var version int
 	mockService := &service.MockSaleService{
-		UpdateSalesFunc: func(id string, req *models.UpdateSaleRequest) (*models.Sale, error) {
+		UpdateSalesFunc: func(ctx context.Context, actor *models.User, id string, req *models.UpdateSaleRequest) (*models.Sale, error) {
+			version = req.Version
+			total, _ := models.ItemsTotal(req.Items)
 			return &models.Sale{
-				ID:       expectedID,
-				Product:  req.Product,
-				Quantity: req.Quantity,
-				Price:    req.Price,
+				ID:      expectedID,
+				Total:   total,
+				Version: req.Version + 1,
 			}, nil
 		},
 	}
@@ -226,14 +662,15 @@ func TestUpdateSale_Success(t *testing.T) {
 	router := setupRouter(handler)
 
 	reqBody := models.UpdateSaleRequest{
-		Product:  "Updated Product",
-		Quantity: 5,
-		Price:    15000,
+		Items: []models.SaleItemRequest{
+			{Product: "Updated Product", Quantity: 5, Price: models.NewMoney(15000)},
+		},
 	}
 
 	jsonBody, _ := json.Marshal(reqBody)
 	req, _ := http.NewRequest("PUT", "/sales/"+expectedID.String(), bytes.NewBuffer(jsonBody))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"3"`)
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
@@ -242,6 +679,14 @@ func TestUpdateSale_Success(t *testing.T) {
 		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
 	}
 
+	if version != 3 {
+		t.Errorf("Expected version 3 from If-Match, got %d", version)
+	}
+
+	if etag := w.Header().Get("ETag"); etag != `"4"` {
+		t.Errorf("Expected ETag of the new version, got %q", etag)
+	}
+
 	var response Response
 	json.Unmarshal(w.Body.Bytes(), &response)
 
@@ -257,6 +702,7 @@ func TestUpdateSale_BadRequest(t *testing.T) {
 
 	req, _ := http.NewRequest("PUT", "/sales/"+uuid.New().String(), bytes.NewBuffer([]byte("invalid json")))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"1"`)
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
@@ -266,9 +712,99 @@ func TestUpdateSale_BadRequest(t *testing.T) {
 	}
 }
 
+func TestPatchSale_KeepsAbsentFields(t *testing.T) {
+	var got *models.UpdateSaleRequest
+	mockService := &service.MockSaleService{
+		UpdateSalesFunc: func(ctx context.Context, actor *models.User, id string, req *models.UpdateSaleRequest) (*models.Sale, error) {
+			got = req
+			return &models.Sale{}, nil
+		},
+	}
+	router := setupRouter(NewSaleHandler(mockService))
+
+	req, _ := http.NewRequest("PATCH", "/sales/"+uuid.New().String(), bytes.NewBufferString(`{"name":null,"is_debt":false}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"1"`)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	if !got.Name.Null || !got.IsDebt.Set || got.IsDebt.Value || got.CustomerID.Set || got.AmountReceived.Set || got.Items != nil {
+		t.Errorf("Expected only name and is_debt to be set, got %+v", got)
+	}
+}
+
+func TestUpdateSale_PreconditionRequired(t *testing.T) {
+	called := false
+	mockService := &service.MockSaleService{
+		UpdateSalesFunc: func(ctx context.Context, actor *models.User, id string, req *models.UpdateSaleRequest) (*models.Sale, error) {
+			called = true
+			return &models.Sale{}, nil
+		},
+	}
+	router := setupRouter(NewSaleHandler(mockService))
+
+	req, _ := http.NewRequest("PUT", "/sales/"+uuid.New().String(), bytes.NewBufferString(`{"name":"Budi"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusPreconditionRequired {
+		t.Errorf("Expected status %d, got %d", http.StatusPreconditionRequired, w.Code)
+	}
+	if called {
+		t.Error("Expected the update not to run")
+	}
+}
+
+func TestUpdateSale_PreconditionFailed(t *testing.T) {
+	mockService := &service.MockSaleService{
+		UpdateSalesFunc: func(ctx context.Context, actor *models.User, id string, req *models.UpdateSaleRequest) (*models.Sale, error) {
+			return nil, service.ErrSaleModified
+		},
+	}
+	router := setupRouter(NewSaleHandler(mockService))
+
+	req, _ := http.NewRequest("PUT", "/sales/"+uuid.New().String(), bytes.NewBufferString(`{"name":"Budi"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"1"`)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected status %d, got %d", http.StatusPreconditionFailed, w.Code)
+	}
+}
+
+func TestIfMatchVersion(t *testing.T) {
+	tests := map[string]int{
+		`"7"`:   7,
+		`W/"7"`: 7,
+		` "12"`: 12,
+		`7`:     0,
+		`"abc"`: 0,
+		`"0"`:   0,
+		`*`:     models.AnyVersion,
+		` * `:   models.AnyVersion,
+		`W/*`:   0,
+	}
+
+	for header, expected := range tests {
+		if got := ifMatchVersion(header); got != expected {
+			t.Errorf("ifMatchVersion(%q) = %d, expected %d", header, got, expected)
+		}
+	}
+}
+
 func TestDeleteSale_Success(t *testing.T) {
 	mockService := &service.MockSaleService{
-		DeleteSalesFunc: func(id string) error {
+		DeleteSalesFunc: func(ctx context.Context, actor *models.User, id string) error {
 			return nil
 		},
 	}
@@ -294,8 +830,8 @@ func TestDeleteSale_Success(t *testing.T) {
 
 func TestDeleteSale_NotFound(t *testing.T) {
 	mockService := &service.MockSaleService{
-		DeleteSalesFunc: func(id string) error {
-			return errors.New("sale not found")
+		DeleteSalesFunc: func(ctx context.Context, actor *models.User, id string) error {
+			return service.ErrSaleNotFound
 		},
 	}
 
@@ -310,3 +846,148 @@ func TestDeleteSale_NotFound(t *testing.T) {
 		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
 	}
 }
+
+func TestDeleteSale_Forbidden(t *testing.T) {
+	mockService := &service.MockSaleService{
+		DeleteSalesFunc: func(ctx context.Context, actor *models.User, id string) error {
+			return service.ErrForbidden
+		},
+	}
+
+	router := setupRouter(NewSaleHandler(mockService))
+
+	req, _ := http.NewRequest("DELETE", "/sales/"+uuid.New().String(), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestGetSaleHistory_Success(t *testing.T) {
+	saleID := uuid.New()
+	mockService := &service.MockSaleService{
+		GetSaleHistoryFunc: func(ctx context.Context, id string) ([]*models.AuditEntry, error) {
+			return []*models.AuditEntry{
+				{ID: 1, Entity: models.AuditEntitySale, EntityID: saleID, Action: models.AuditActionCreate, After: []byte(`{"total":"12000.00"}`)},
+				{ID: 2, Entity: models.AuditEntitySale, EntityID: saleID, Action: models.AuditActionDelete, Before: []byte(`{"total":"12000.00"}`)},
+			}, nil
+		},
+	}
+
+	router := setupRouter(NewSaleHandler(mockService))
+
+	req, _ := http.NewRequest("GET", "/sales/"+saleID.String()+"/history", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var body struct {
+		Data []struct {
+			Action string          `json:"action"`
+			Before json.RawMessage `json:"before"`
+			After  json.RawMessage `json:"after"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(body.Data) != 2 || body.Data[1].Action != models.AuditActionDelete {
+		t.Fatalf("Expected create and delete entries, got %+v", body.Data)
+	}
+	if string(body.Data[0].Before) != "null" || string(body.Data[1].After) != "null" {
+		t.Errorf("Expected missing snapshots as null, got %s and %s", body.Data[0].Before, body.Data[1].After)
+	}
+}
+
+func TestGetSaleHistory_NotFound(t *testing.T) {
+	mockService := &service.MockSaleService{
+		GetSaleHistoryFunc: func(ctx context.Context, id string) ([]*models.AuditEntry, error) {
+			return nil, service.ErrSaleNotFound
+		},
+	}
+
+	router := setupRouter(NewSaleHandler(mockService))
+
+	req, _ := http.NewRequest("GET", "/sales/"+uuid.New().String()+"/history", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestGetTrash_Success(t *testing.T) {
+	deletedAt := time.Now()
+	mockService := &service.MockSaleService{
+		GetTrashFunc: func(ctx context.Context, params *models.SaleListParams) (*models.SalePage, error) {
+			return &models.SalePage{
+				Sales: []*models.Sale{{ID: uuid.New(), DeletedAt: &deletedAt}},
+				Meta:  models.PageMeta{Page: 1, Limit: 20, Total: 1, TotalPages: 1},
+			}, nil
+		},
+	}
+
+	router := setupRouter(NewSaleHandler(mockService))
+
+	req, _ := http.NewRequest("GET", "/sales/trash", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var body struct {
+		Data []struct {
+			DeletedAt *time.Time `json:"deleted_at"`
+		} `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &body)
+
+	if len(body.Data) != 1 || body.Data[0].DeletedAt == nil {
+		t.Errorf("Expected one deleted sale, got %s", w.Body.String())
+	}
+}
+
+func TestRestoreSale_NotInTrash(t *testing.T) {
+	mockService := &service.MockSaleService{
+		RestoreSaleFunc: func(ctx context.Context, actor *models.User, id string) (*models.Sale, error) {
+			return nil, service.ErrSaleNotFound
+		},
+	}
+
+	router := setupRouter(NewSaleHandler(mockService))
+
+	req, _ := http.NewRequest("POST", "/sales/"+uuid.New().String()+"/restore", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestDeleteSale_DatabaseError(t *testing.T) {
+	mockService := &service.MockSaleService{
+		DeleteSalesFunc: func(ctx context.Context, actor *models.User, id string) error {
+			return errors.New("connection reset")
+		},
+	}
+
+	router := setupRouter(NewSaleHandler(mockService))
+
+	req, _ := http.NewRequest("DELETE", "/sales/"+uuid.New().String(), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+}
@@ -0,0 +1,40 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestNew_AddsRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, "json", slog.LevelInfo).With("component", "test")
+
+	logger.InfoContext(WithRequestID(context.Background(), "req-1"), "hello")
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("Expected a JSON line, got %q", buf.String())
+	}
+
+	if line["request_id"] != "req-1" || line["component"] != "test" {
+		t.Errorf("Expected the request ID and attributes, got %v", line)
+	}
+}
+
+func TestNew_Level(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, "text", slog.LevelWarn)
+
+	logger.Info("hidden")
+	if buf.Len() != 0 {
+		t.Errorf("Expected info to be dropped, got %q", buf.String())
+	}
+
+	logger.Warn("shown")
+	if buf.Len() == 0 {
+		t.Error("Expected warn to be written")
+	}
+}
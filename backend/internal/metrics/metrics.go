@@ -0,0 +1,87 @@
+// Package metrics exports Prometheus metrics for the API: request latency per
+// route, the database connection pool and the shop's sales.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"pencatatan/internal/models"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "pencatatan"
+
+// Metrics holds the collectors of one API instance.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requestDuration *prometheus.HistogramVec
+	salesCreated    *prometheus.CounterVec
+	revenue         *prometheus.CounterVec
+	debtIssued      *prometheus.CounterVec
+}
+
+// New creates the collectors, including gauges read from db.Stats() on every
+// scrape.
+func New(db *sql.DB) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Time taken to answer HTTP requests, by route and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		salesCreated: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "sales_created_total",
+			Help:      "Sales recorded, by source (api or import).",
+		}, []string{"source"}),
+		revenue: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "revenue_total",
+			Help:      "Total of the sales recorded, in major currency units.",
+		}, []string{"currency"}),
+		debtIssued: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "debt_issued_total",
+			Help:      "Unpaid part of debt sales when they were recorded, in major currency units.",
+		}, []string{"currency"}),
+	}
+
+	m.registry.MustRegister(
+		m.requestDuration,
+		m.salesCreated,
+		m.revenue,
+		m.debtIssued,
+		collectors.NewDBStatsCollector(db, namespace),
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRequest records how long a request to route took.
+func (m *Metrics) ObserveRequest(method, route, status string, seconds float64) {
+	m.requestDuration.WithLabelValues(method, route, status).Observe(seconds)
+}
+
+// SaleRecorded counts a saved sale. Debt is the part of total left unpaid.
+func (m *Metrics) SaleRecorded(source, currency string, total, debt models.Money) {
+	m.salesCreated.WithLabelValues(source).Inc()
+	if total > 0 {
+		m.revenue.WithLabelValues(currency).Add(total.Float64())
+	}
+	if debt > 0 {
+		m.debtIssued.WithLabelValues(currency).Add(debt.Float64())
+	}
+}
@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"pencatatan/internal/models"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func newTestMetrics(t *testing.T) *Metrics {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to open sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return New(db)
+}
+
+func TestSaleRecorded(t *testing.T) {
+	m := newTestMetrics(t)
+
+	m.SaleRecorded("api", "IDR", models.NewMoney(15000), 0)
+	m.SaleRecorded("import", "IDR", models.NewMoney(20000), models.NewMoney(5000))
+
+	if got := testutil.ToFloat64(m.salesCreated.WithLabelValues("api")); got != 1 {
+		t.Errorf("Expected 1 sale from the API, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.revenue.WithLabelValues("IDR")); got != 35000 {
+		t.Errorf("Expected revenue 35000, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.debtIssued.WithLabelValues("IDR")); got != 5000 {
+		t.Errorf("Expected debt issued 5000, got %v", got)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	m := newTestMetrics(t)
+	m.ObserveRequest("GET", "/api/sales/:id", "200", 0.01)
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	body := w.Body.String()
+	for _, want := range []string{
+		`pencatatan_http_request_duration_seconds_count{method="GET",route="/api/sales/:id",status="200"} 1`,
+		`go_sql_max_open_connections{db_name="pencatatan"}`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %s in the metrics", want)
+		}
+	}
+}
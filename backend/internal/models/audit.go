@@ -0,0 +1,34 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Entities and actions recorded in the audit log.
+const (
+	AuditEntitySale = "sale"
+
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionRestore = "restore"
+	AuditActionPurge   = "purge"
+)
+
+// AuditEntry is one change recorded in the audit log. Before is null for a
+// create and After is null for a delete; a purge has neither. ActorUsername is empty when the change
+// was made outside the API or the user no longer exists.
+type AuditEntry struct {
+	ID            int64           `json:"id" db:"id"`
+	Entity        string          `json:"entity" db:"entity"`
+	EntityID      uuid.UUID       `json:"entity_id" db:"entity_id"`
+	Action        string          `json:"action" db:"action"`
+	ActorID       *uuid.UUID      `json:"actor_id" db:"actor_id"`
+	ActorUsername string          `json:"actor_username"`
+	Before        json.RawMessage `json:"before" db:"before"`
+	After         json.RawMessage `json:"after" db:"after"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Customer struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`
+	Phone       *string   `json:"phone" db:"phone"`
+	Address     *string   `json:"address" db:"address"`
+	Notes       *string   `json:"notes" db:"notes"`
+	CreditLimit Money     `json:"credit_limit" db:"credit_limit"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type CreateCustomerRequest struct {
+	Name        string  `json:"name" binding:"required,max=255"`
+	Phone       *string `json:"phone" binding:"omitempty,max=32"`
+	Address     *string `json:"address"`
+	Notes       *string `json:"notes"`
+	CreditLimit Money   `json:"credit_limit" binding:"omitempty,gte=0"`
+}
+
+// UpdateCustomerRequest only changes the fields that are present.
+type UpdateCustomerRequest struct {
+	Name        *string `json:"name" binding:"omitempty,min=1,max=255"`
+	Phone       *string `json:"phone" binding:"omitempty,max=32"`
+	Address     *string `json:"address"`
+	Notes       *string `json:"notes"`
+	CreditLimit *Money  `json:"credit_limit" binding:"omitempty,gte=0"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DebtPayment is a repayment of (part of) the unpaid amount of a sale.
+type DebtPayment struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	SaleID     uuid.UUID  `json:"sale_id" db:"sale_id"`
+	CustomerID *uuid.UUID `json:"customer_id" db:"customer_id"`
+	Amount     Money      `json:"amount" db:"amount"`
+	PaidAt     time.Time  `json:"paid_at" db:"paid_at"`
+	Note       *string    `json:"note" db:"note"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+type CreateDebtPaymentRequest struct {
+	Amount Money      `json:"amount" binding:"required,gt=0"`
+	PaidAt *time.Time `json:"paid_at"`
+	Note   *string    `json:"note"`
+}
+
+// SaleDebt is the debt position of a single sale.
+type SaleDebt struct {
+	SaleID          uuid.UUID      `json:"sale_id" db:"sale_id"`
+	CustomerID      *uuid.UUID     `json:"customer_id" db:"customer_id"`
+	Name            string         `json:"name" db:"name"`
+	TransactionDate time.Time      `json:"transaction_date" db:"transaction_date"`
+	Total           Money          `json:"total" db:"total"`
+	AmountReceived  Money          `json:"amount_received" db:"amount_received"`
+	DebtAmount      Money          `json:"debt_amount" db:"debt_amount"`
+	PaidAmount      Money          `json:"paid_amount" db:"paid_amount"`
+	Outstanding     Money          `json:"outstanding" db:"outstanding"`
+	Payments        []*DebtPayment `json:"payments,omitempty"`
+}
+
+// CustomerBalance sums the debt positions of every sale of a customer.
+type CustomerBalance struct {
+	CustomerID  uuid.UUID `json:"customer_id"`
+	TotalDebt   Money     `json:"total_debt"`
+	TotalPaid   Money     `json:"total_paid"`
+	Outstanding Money     `json:"outstanding"`
+	OpenSales   int       `json:"open_sales"`
+}
+
+type SalePaymentResult struct {
+	Payment *DebtPayment `json:"payment"`
+	Debt    *SaleDebt    `json:"debt"`
+}
+
+type CustomerPaymentResult struct {
+	Payments []*DebtPayment   `json:"payments"`
+	Balance  *CustomerBalance `json:"balance"`
+}
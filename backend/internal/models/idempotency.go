@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdempotencyKey records a request sent with an Idempotency-Key header.
+// RequestHash fingerprints the request, so the key cannot be reused for a
+// different one. StatusCode and Response are nil until the request finishes.
+// ClaimToken identifies the request that holds the key.
+type IdempotencyKey struct {
+	UserID      uuid.UUID `json:"user_id" db:"user_id"`
+	Key         string    `json:"key" db:"key"`
+	RequestHash string    `json:"-" db:"request_hash"`
+	ClaimToken  uuid.UUID `json:"-" db:"claim_token"`
+	StatusCode  *int      `json:"status_code" db:"status_code"`
+	Response    []byte    `json:"-" db:"response"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at" db:"expires_at"`
+}
+
+// Completed reports whether the response of the request has been stored.
+func (k *IdempotencyKey) Completed() bool {
+	return k.StatusCode != nil
+}
@@ -0,0 +1,188 @@
+package models
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the ISO 4217 code used when a sale does not specify one.
+const DefaultCurrency = "IDR"
+
+// moneyScale is the number of minor units in one major unit. It matches the
+// two decimal places of the NUMERIC columns in the database.
+const moneyScale = 100
+
+var ErrInvalidMoney = errors.New("invalid money amount")
+
+var errMoneyRange = fmt.Errorf("%w: out of range", ErrInvalidMoney)
+
+// Money is an exact amount in minor units (1 rupiah = 100 sen), so sums and
+// comparisons never suffer from float64 rounding. It is encoded in JSON as a
+// plain decimal number and stored in Postgres as NUMERIC.
+type Money int64
+
+// NewMoney returns the Money value of a whole number of major units.
+func NewMoney(major int64) Money {
+	return Money(major * moneyScale)
+}
+
+// ParseMoney parses a decimal string such as "12500", "-3.5" or "10.25".
+// More than two decimal places is rejected instead of silently rounded.
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, ErrInvalidMoney
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	whole, frac, hasFrac := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return 0, ErrInvalidMoney
+	}
+	if hasFrac && frac == "" {
+		return 0, ErrInvalidMoney
+	}
+	if len(frac) > 2 {
+		// Postgres may return trailing zeros beyond our scale, e.g. "10.500".
+		if strings.Trim(frac[2:], "0") != "" {
+			return 0, fmt.Errorf("%w: more than 2 decimal places", ErrInvalidMoney)
+		}
+		frac = frac[:2]
+	}
+	for len(frac) < 2 {
+		frac += "0"
+	}
+
+	if whole == "" {
+		whole = "0"
+	}
+	if !isDigits(whole) || !isDigits(frac) {
+		return 0, ErrInvalidMoney
+	}
+
+	minor, _ := strconv.ParseInt(frac, 10, 64)
+	major, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || major > (math.MaxInt64-minor)/moneyScale {
+		return 0, errMoneyRange
+	}
+
+	amount := major*moneyScale + minor
+	if negative {
+		amount = -amount
+	}
+
+	return Money(amount), nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Mul returns the amount multiplied by a quantity, or ErrInvalidMoney when the
+// result does not fit.
+func (m Money) Mul(quantity int) (Money, error) {
+	product := m * Money(quantity)
+	if quantity != 0 && (product/Money(quantity) != m || (quantity == -1 && m == math.MinInt64)) {
+		return 0, errMoneyRange
+	}
+	return product, nil
+}
+
+// Add returns the sum of two amounts, or ErrInvalidMoney when it does not fit.
+func (m Money) Add(other Money) (Money, error) {
+	sum := m + other
+	if (other > 0 && sum < m) || (other < 0 && sum > m) {
+		return 0, errMoneyRange
+	}
+	return sum, nil
+}
+
+// Float64 returns the amount in major units. It is only meant for output
+// formats that need a number, such as spreadsheet cells.
+func (m Money) Float64() float64 {
+	return float64(m) / moneyScale
+}
+
+// String formats the amount with exactly two decimal places, e.g. "12500.50".
+func (m Money) String() string {
+	sign := ""
+	v := int64(m)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, v/moneyScale, v%moneyScale)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts both JSON numbers and quoted decimal strings. The
+// number literal is parsed as text so no precision is lost through float64.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	s := string(data)
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+
+	v, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+// Scan implements sql.Scanner for NUMERIC columns, which pgx returns as text.
+func (m *Money) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*m = 0
+		return nil
+	case string:
+		parsed, err := ParseMoney(v)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	case []byte:
+		return m.Scan(string(v))
+	case int64:
+		*m = NewMoney(v)
+		return nil
+	case float64:
+		*m = Money(math.Round(v * moneyScale))
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into Money", src)
+	}
+}
+
+// Value implements driver.Valuer, sending the amount as an exact decimal.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Money
+		wantErr bool
+	}{
+		{in: "12500", want: 1250000},
+		{in: "12500.5", want: 1250050},
+		{in: "12500.50", want: 1250050},
+		{in: "0.10", want: 10},
+		{in: "-3.25", want: -325},
+		{in: "10.500", want: 1050},
+		{in: "10.505", wantErr: true},
+		{in: "abc", wantErr: true},
+		{in: "1.", wantErr: true},
+		{in: "", wantErr: true},
+		{in: "92233720368547758.07", want: Money(math.MaxInt64)},
+		{in: "92233720368547758.08", wantErr: true},
+		{in: "92233720368547759", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseMoney(%q) expected error, got %v", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseMoney(%q) unexpected error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseMoney(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMoney_MulOverflow(t *testing.T) {
+	if got, err := NewMoney(12500).Mul(3); err != nil || got != NewMoney(37500) {
+		t.Errorf("Expected 37500.00, got %s (%v)", got, err)
+	}
+
+	if _, err := Money(9999999999).Mul(math.MaxInt32); !errors.Is(err, ErrInvalidMoney) {
+		t.Errorf("Expected an out of range error, got %v", err)
+	}
+}
+
+func TestItemsTotal_OutOfRange(t *testing.T) {
+	items := []SaleItemRequest{
+		{Product: "Beras", Quantity: 100, Price: Money(9999999999)},
+		{Product: "Gula", Quantity: 1, Price: Money(9999999999)},
+	}
+	if _, err := ItemsTotal(items[:1]); err != nil {
+		t.Fatalf("Expected the largest total to fit, got %v", err)
+	}
+
+	if _, err := ItemsTotal(items); !errors.Is(err, ErrInvalidMoney) {
+		t.Errorf("Expected a total above MaxSaleTotal to be rejected, got %v", err)
+	}
+}
+
+func TestMoney_JSONRoundTrip(t *testing.T) {
+	var m Money
+	if err := json.Unmarshal([]byte("0.1"), &m); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var sum Money
+	for i := 0; i < 10; i++ {
+		sum += m
+	}
+	if sum != NewMoney(1) {
+		t.Errorf("Expected 10 x 0.1 to equal 1.00, got %s", sum)
+	}
+
+	out, err := json.Marshal(sum)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if string(out) != "1.00" {
+		t.Errorf("Expected 1.00, got %s", out)
+	}
+
+	if err := json.Unmarshal([]byte(`"2500.75"`), &m); err != nil {
+		t.Fatalf("Expected no error for quoted amount, got %v", err)
+	}
+	if m != 250075 {
+		t.Errorf("Expected 250075, got %d", m)
+	}
+}
+
+func TestMoney_Scan(t *testing.T) {
+	var m Money
+	if err := m.Scan("15000.25"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if m != 1500025 {
+		t.Errorf("Expected 1500025, got %d", m)
+	}
+
+	if err := m.Scan(nil); err != nil || m != 0 {
+		t.Errorf("Expected NULL to scan as 0, got %d (%v)", m, err)
+	}
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"encoding/json"
+)
+
+// Optional is a field of a partial update. It tells a field that was left out
+// of the JSON (Set is false) from one sent as null (Set and Null are true) or
+// with a value. Fields of this type are tagged omitzero so that encoding an
+// unset field leaves it out again.
+type Optional[T any] struct {
+	Value T
+	Set   bool
+	Null  bool
+}
+
+// Some returns an Optional set to v.
+func Some[T any](v T) Optional[T] {
+	return Optional[T]{Value: v, Set: true}
+}
+
+// UnmarshalJSON is only called for fields present in the JSON, which is what
+// marks the field as set.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	var value T
+	o.Set = true
+	o.Null = string(data) == "null"
+	if o.Null {
+		o.Value = value
+		return nil
+	}
+
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	o.Value = value
+	return nil
+}
+
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.Set || o.Null {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.Value)
+}
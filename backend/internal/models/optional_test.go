@@ -0,0 +1,55 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestUpdateSaleRequestDecode(t *testing.T) {
+	var req UpdateSaleRequest
+	body := `{"name": "", "customer_id": null, "amount_received": 0, "is_debt": false}`
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !req.Name.Set || req.Name.Null || req.Name.Value != "" {
+		t.Errorf("name = %+v, want set to empty", req.Name)
+	}
+	if !req.CustomerID.Set || !req.CustomerID.Null {
+		t.Errorf("customer_id = %+v, want set to null", req.CustomerID)
+	}
+	if !req.AmountReceived.Set || req.AmountReceived.Value != 0 {
+		t.Errorf("amount_received = %+v, want set to zero", req.AmountReceived)
+	}
+	if !req.IsDebt.Set || req.IsDebt.Value {
+		t.Errorf("is_debt = %+v, want set to false", req.IsDebt)
+	}
+
+	var empty UpdateSaleRequest
+	if err := json.Unmarshal([]byte(`{}`), &empty); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if empty.Name.Set || empty.CustomerID.Set || empty.AmountReceived.Set || empty.IsDebt.Set {
+		t.Errorf("absent fields must stay unset, got %+v", empty)
+	}
+}
+
+func TestUpdateSaleRequestEncode(t *testing.T) {
+	req := UpdateSaleRequest{
+		Name:       Some("Bu Sri"),
+		CustomerID: Optional[uuid.UUID]{Set: true, Null: true},
+		IsDebt:     Some(false),
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `{"name":"Bu Sri","customer_id":null,"is_debt":false}`
+	if string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Product struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	SKU       string    `json:"sku" db:"sku"`
+	Name      string    `json:"name" db:"name"`
+	Unit      string    `json:"unit" db:"unit"`
+	Price     Money     `json:"price" db:"price"`
+	CostPrice Money     `json:"cost_price" db:"cost_price"`
+	IsActive  bool      `json:"is_active" db:"is_active"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type CreateProductRequest struct {
+	SKU       string `json:"sku" binding:"required,max=64"`
+	Name      string `json:"name" binding:"required,max=255"`
+	Unit      string `json:"unit" binding:"omitempty,max=32"`
+	Price     Money  `json:"price" binding:"required,gt=0"`
+	CostPrice Money  `json:"cost_price" binding:"omitempty,gte=0"`
+	IsActive  *bool  `json:"is_active"`
+}
+
+// UpdateProductRequest only changes the fields that are present.
+type UpdateProductRequest struct {
+	SKU       *string `json:"sku" binding:"omitempty,min=1,max=64"`
+	Name      *string `json:"name" binding:"omitempty,min=1,max=255"`
+	Unit      *string `json:"unit" binding:"omitempty,min=1,max=32"`
+	Price     *Money  `json:"price" binding:"omitempty,gt=0"`
+	CostPrice *Money  `json:"cost_price" binding:"omitempty,gte=0"`
+	IsActive  *bool   `json:"is_active"`
+}
+
+// PriceListItem is one line of the price list shown at the cashier.
+type PriceListItem struct {
+	ID    uuid.UUID `json:"id"`
+	SKU   string    `json:"sku"`
+	Name  string    `json:"name"`
+	Unit  string    `json:"unit"`
+	Price Money     `json:"price"`
+}
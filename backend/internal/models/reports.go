@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DebtAgingBuckets splits an outstanding amount by how many days ago the sale
+// happened.
+type DebtAgingBuckets struct {
+	Days0To7   Money `json:"days_0_7"`
+	Days8To30  Money `json:"days_8_30"`
+	Days31To60 Money `json:"days_31_60"`
+	Over60Days Money `json:"days_over_60"`
+	Total      Money `json:"total"`
+}
+
+type DebtAgingRow struct {
+	CustomerID *uuid.UUID `json:"customer_id"`
+	Name       string     `json:"name"`
+	OpenSales  int        `json:"open_sales"`
+	DebtAgingBuckets
+}
+
+// DebtAgingReport lists customers with the largest overdue amounts first,
+// followed by the totals over all customers.
+type DebtAgingReport struct {
+	Customers []*DebtAgingRow  `json:"customers"`
+	Totals    DebtAgingBuckets `json:"totals"`
+}
+
+// SalesSummaryParams are the query parameters accepted by
+// GET /api/reports/summary.
+type SalesSummaryParams struct {
+	Granularity string `form:"granularity" binding:"omitempty,oneof=day week month"`
+	From        string `form:"from"`
+	To          string `form:"to"`
+}
+
+// SalesSummaryFilter is the validated form of SalesSummaryParams. To is
+// exclusive and Timezone is the IANA name buckets are truncated in.
+type SalesSummaryFilter struct {
+	Granularity string
+	From        time.Time
+	To          time.Time
+	Timezone    string
+}
+
+// SalesSummaryFigures are the totals reported for one period. CashReceived
+// is money actually taken in: the paid part of each sale plus any debt
+// repayments made in the period.
+type SalesSummaryFigures struct {
+	Revenue      Money `json:"revenue"`
+	Transactions int64 `json:"transactions"`
+	ItemsSold    int64 `json:"items_sold"`
+	DebtIssued   Money `json:"debt_issued"`
+	CashReceived Money `json:"cash_received"`
+}
+
+// SalesSummaryBucket holds the figures of the period starting on Period, a
+// date in the business time zone.
+type SalesSummaryBucket struct {
+	Period string `json:"period"`
+	SalesSummaryFigures
+}
+
+type SalesSummary struct {
+	Granularity string                `json:"granularity"`
+	Timezone    string                `json:"timezone"`
+	From        time.Time             `json:"from"`
+	To          time.Time             `json:"to"`
+	Buckets     []*SalesSummaryBucket `json:"buckets"`
+	Totals      SalesSummaryFigures   `json:"totals"`
+}
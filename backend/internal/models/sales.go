@@ -6,35 +6,215 @@ import (
 	"github.com/google/uuid"
 )
 
+// Sale is the header of one receipt. The purchased products are its Items;
+// Total is the sum of their subtotals.
 type Sale struct {
-	ID              uuid.UUID `json:"id" db:"id"`
-	Name            string    `json:"name" db:"name"`
-	Product         string    `json:"product" db:"product"`
-	Quantity        int       `json:"quantity" db:"quantity"`
-	Price           float64   `json:"price" db:"price"`
-	Total           float64   `json:"total" db:"total"`
-	AmountReceived  float64   `json:"amount_received" db:"amount_received"`
-	ChangeAmount    float64   `json:"change_amount" db:"change_amount"`
-	TransactionDate time.Time `json:"transaction_date" db:"transaction_date"`
-	IsDebt          bool      `json:"is_debt" db:"is_debt"`
-	CreatedAt       time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+	ID              uuid.UUID   `json:"id" db:"id"`
+	Name            string      `json:"name" db:"name"`
+	CustomerID      *uuid.UUID  `json:"customer_id" db:"customer_id"`
+	Items           []*SaleItem `json:"items"`
+	Total           Money       `json:"total" db:"total"`
+	AmountReceived  Money       `json:"amount_received" db:"amount_received"`
+	ChangeAmount    Money       `json:"change_amount" db:"change_amount"`
+	Currency        string      `json:"currency" db:"currency"`
+	TransactionDate time.Time   `json:"transaction_date" db:"transaction_date"`
+	IsDebt          bool        `json:"is_debt" db:"is_debt"`
+	CreatedBy       *uuid.UUID  `json:"created_by" db:"created_by"`
+	Version         int         `json:"version" db:"version"`
+	CreatedAt       time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time   `json:"updated_at" db:"updated_at"`
+	DeletedAt       *time.Time  `json:"deleted_at" db:"deleted_at"`
 }
 
+// SaleItem is one line of a receipt.
+type SaleItem struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	SaleID    uuid.UUID  `json:"sale_id" db:"sale_id"`
+	ProductID *uuid.UUID `json:"product_id" db:"product_id"`
+	Product   string     `json:"product" db:"product"`
+	Quantity  int        `json:"quantity" db:"quantity"`
+	Price     Money      `json:"price" db:"price"`
+	Subtotal  Money      `json:"subtotal" db:"subtotal"`
+}
+
+// SaleItemRequest is one receipt line in a create or update request. When
+// ProductID is set the product name, and the price if omitted, come from the
+// catalog.
+type SaleItemRequest struct {
+	ProductID *uuid.UUID `json:"product_id"`
+	Product   string     `json:"product" binding:"required_without=ProductID"`
+	Quantity  int        `json:"quantity" binding:"required,gt=0,max=2147483647"`
+	Price     Money      `json:"price" binding:"required_without=ProductID,gte=0,max=9999999999"`
+}
+
+// Subtotal returns price times quantity for the line, or ErrInvalidMoney when
+// it overflows.
+func (i *SaleItemRequest) Subtotal() (Money, error) {
+	return i.Price.Mul(i.Quantity)
+}
+
+// CreateSalesRequest creates a sale. TransactionDate defaults to now; only
+// users who manage sales may set it, to record past sales. CreatedBy is filled
+// from the logged in user, never from the request body.
 type CreateSalesRequest struct {
-	Name           string  `json:"name"`
-	Product        string  `json:"product" binding:"required"`
-	Quantity       int     `json:"quantity" binding:"required,gt=0"`
-	Price          float64 `json:"price" binding:"required,gt=0"`
-	AmountReceived float64 `json:"amount_received" binding:"omitempty"`
-	IsDebt         bool    `json:"is_debt"`
+	Name            string            `json:"name"`
+	CustomerID      *uuid.UUID        `json:"customer_id"`
+	Items           []SaleItemRequest `json:"items" binding:"required,min=1,dive"`
+	AmountReceived  Money             `json:"amount_received" binding:"omitempty,gte=0"`
+	Currency        string            `json:"currency" binding:"omitempty,iso4217"`
+	IsDebt          bool              `json:"is_debt"`
+	TransactionDate *time.Time        `json:"transaction_date"`
+	CreatedBy       *uuid.UUID        `json:"-"`
 }
 
+// AnyVersion is the Version of an update sent with If-Match: *, which applies
+// to whatever version the sale is at.
+const AnyVersion = -1
+
+// UpdateSaleRequest partially updates a sale: fields left out are unchanged,
+// while null, empty, zero and false are applied. A null name clears it and a
+// null customer_id detaches the customer. When Items is given it replaces
+// every line of the receipt. Version is the version the client last read,
+// taken from the If-Match header; the update is refused when the sale has
+// changed since, unless Version is AnyVersion.
 type UpdateSaleRequest struct {
-	Name           string  `json:"name"`
-	Product        string  `json:"product"`
-	Quantity       int     `json:"quantity" binding:"omitempty,gt=0"`
-	Price          float64 `json:"price" binding:"omitempty,gte=0"`
-	AmountReceived float64 `json:"amount_received" binding:"omitempty"`
-	IsDebt         bool    `json:"is_debt" binding:"omitempty"`
+	Name           Optional[string]    `json:"name,omitzero"`
+	CustomerID     Optional[uuid.UUID] `json:"customer_id,omitzero"`
+	Items          []SaleItemRequest   `json:"items,omitempty" binding:"omitempty,min=1,dive"`
+	AmountReceived Optional[Money]     `json:"amount_received,omitzero"`
+	IsDebt         Optional[bool]      `json:"is_debt,omitzero"`
+	Version        int                 `json:"-"`
+}
+
+// MaxSaleTotal is the largest total the NUMERIC(12, 2) columns can store.
+const MaxSaleTotal Money = 999999999999
+
+// ItemsTotal returns the sum of the line subtotals, or ErrInvalidMoney when it
+// is larger than MaxSaleTotal.
+func ItemsTotal(items []SaleItemRequest) (Money, error) {
+	var total Money
+	for i := range items {
+		subtotal, err := items[i].Subtotal()
+		if err == nil {
+			total, err = total.Add(subtotal)
+		}
+		if err != nil || total > MaxSaleTotal {
+			return 0, errMoneyRange
+		}
+	}
+	return total, nil
+}
+
+// SaleListParams are the query parameters accepted by GET /api/sales.
+type SaleListParams struct {
+	Page       int    `form:"page" binding:"omitempty,min=1"`
+	Limit      int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	Cursor     string `form:"cursor"`
+	From       string `form:"from"`
+	To         string `form:"to"`
+	IsDebt     *bool  `form:"is_debt"`
+	Product    string `form:"product"`
+	Name       string `form:"name"`
+	CustomerID string `form:"customer_id" binding:"omitempty,uuid"`
+	Sort       string `form:"sort" binding:"omitempty,oneof=transaction_date created_at total"`
+	Order      string `form:"order" binding:"omitempty,oneof=asc desc"`
+}
+
+// SaleFilter is the validated form of SaleListParams used by the repository.
+// To is exclusive. Deleted lists the sales in the trash instead of live ones.
+type SaleFilter struct {
+	Deleted    bool
+	From       *time.Time
+	To         *time.Time
+	IsDebt     *bool
+	Product    string
+	Name       string
+	CustomerID *uuid.UUID
+	Sort       string
+	Desc       bool
+	Limit      int
+	Offset     int
+	After      *Cursor
+}
+
+type SalePage struct {
+	Sales []*Sale
+	Meta  PageMeta
+}
+
+// SortValue returns the value of the given sort field as stored in a cursor.
+func (s *Sale) SortValue(field string) string {
+	switch field {
+	case "created_at":
+		return s.CreatedAt.Format(time.RFC3339Nano)
+	case "total":
+		return s.Total.String()
+	case "deleted_at":
+		if s.DeletedAt == nil {
+			return ""
+		}
+		return s.DeletedAt.Format(time.RFC3339Nano)
+	default:
+		return s.TransactionDate.Format(time.RFC3339Nano)
+	}
+}
+
+// SaleSearchParams are the query parameters accepted by GET /api/sales/search.
+type SaleSearchParams struct {
+	Q     string `form:"q" binding:"required"`
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// SaleSearchResult is a sale matching a search query. Highlight holds the
+// name and the receipt's products as HTML: the text is escaped and matched
+// terms are wrapped in <mark> tags, so it can be inserted into a page as is.
+type SaleSearchResult struct {
+	Sale
+	Rank      float64       `json:"rank"`
+	Highlight SaleHighlight `json:"highlight"`
+}
+
+type SaleHighlight struct {
+	Name     string `json:"name"`
+	Products string `json:"products"`
+}
+
+// SaleExportParams are the query parameters accepted by GET /api/sales/export.
+type SaleExportParams struct {
+	Format string `form:"format" binding:"omitempty,oneof=csv xlsx"`
+	From   string `form:"from"`
+	To     string `form:"to"`
+}
+
+// SaleExportRow is one sale as written to an export file. Products lists the
+// receipt lines as "name x quantity".
+type SaleExportRow struct {
+	Sale
+	Products  string
+	ItemsSold int
+}
+
+// SaleImportParams are the query parameters accepted by POST /api/sales/import.
+type SaleImportParams struct {
+	DryRun bool `form:"dry_run"`
+}
+
+// SaleImportError describes why one CSV line was not imported. Line is the
+// line number in the file, counting the header as line 1.
+type SaleImportError struct {
+	Line   int    `json:"line"`
+	Column string `json:"column,omitempty"`
+	Error  string `json:"error"`
+}
+
+// SaleImportReport summarises a CSV import. Rows counts data lines; several
+// lines sharing a receipt value become one sale.
+type SaleImportReport struct {
+	DryRun   bool              `json:"dry_run"`
+	Rows     int               `json:"rows"`
+	Sales    int               `json:"sales"`
+	Valid    int               `json:"valid"`
+	Imported int               `json:"imported"`
+	Failed   int               `json:"failed"`
+	Errors   []SaleImportError `json:"errors"`
 }
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Role decides what a user may do. See rolePermissions.
+type Role string
+
+const (
+	RoleOwner   Role = "owner"
+	RoleCashier Role = "cashier"
+	RoleViewer  Role = "viewer"
+)
+
+// Permission is one kind of action on the API.
+type Permission string
+
+const (
+	// PermissionViewReports allows reading the reports.
+	PermissionViewReports Permission = "reports:view"
+	// PermissionViewSales allows reading sales, debts, products and customers.
+	PermissionViewSales Permission = "sales:view"
+	// PermissionRecordSales allows creating sales and recording repayments,
+	// and editing or deleting sales dated today.
+	PermissionRecordSales Permission = "sales:record"
+	// PermissionManageSales allows editing and deleting sales of any day and
+	// importing sales.
+	PermissionManageSales Permission = "sales:manage"
+	// PermissionManageCatalog allows changing products and customers.
+	PermissionManageCatalog Permission = "catalog:manage"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleOwner: {
+		PermissionViewReports,
+		PermissionViewSales,
+		PermissionRecordSales,
+		PermissionManageSales,
+		PermissionManageCatalog,
+	},
+	RoleCashier: {
+		PermissionViewReports,
+		PermissionViewSales,
+		PermissionRecordSales,
+	},
+	RoleViewer: {
+		PermissionViewReports,
+	},
+}
+
+type User struct {
+	ID           uuid.UUID `json:"id" db:"id"`
+	Username     string    `json:"username" db:"username"`
+	Name         string    `json:"name" db:"name"`
+	Role         Role      `json:"role" db:"role"`
+	PasswordHash string    `json:"-" db:"password_hash"`
+	IsActive     bool      `json:"is_active" db:"is_active"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Can reports whether the user's role grants the permission.
+func (u *User) Can(permission Permission) bool {
+	return slices.Contains(rolePermissions[u.Role], permission)
+}
+
+// CreateUserRequest creates a login. It is only used by the CLI; there is no
+// sign-up endpoint. Role defaults to cashier.
+type CreateUserRequest struct {
+	Username string `json:"username" binding:"required,min=3,max=64"`
+	Name     string `json:"name" binding:"max=255"`
+	Role     Role   `json:"role" binding:"omitempty,oneof=owner cashier viewer"`
+	Password string `json:"password" binding:"required,min=8,max=72"`
+}
+
+type LoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// RefreshTokenRequest carries the refresh token for POST /api/auth/refresh
+// and POST /api/auth/logout.
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// RefreshToken is a stored refresh token. Only the hash of the token is kept.
+type RefreshToken struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	UserID    uuid.UUID  `json:"user_id" db:"user_id"`
+	TokenHash string     `json:"-" db:"token_hash"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at" db:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// AuthTokens is returned by login and refresh. The access token is a JWT sent
+// as "Authorization: Bearer"; ExpiresIn is its lifetime in seconds.
+type AuthTokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	User         *User  `json:"user"`
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"pencatatan/internal/models"
+
+	"github.com/google/uuid"
+)
+
+const auditColumns = `a.id, a.entity, a.entity_id, a.action, a.actor_id, COALESCE(u.username, ''),
+	a.before, a.after, a.created_at`
+
+// writeAudit appends an entry to the audit log. It takes the transaction of
+// the change so the entry is saved if and only if the change is. before and
+// after are stored as JSON; pass nil for a missing snapshot.
+func writeAudit(ctx context.Context, tx DBTX, entity, action string, entityID uuid.UUID, actorID *uuid.UUID, before, after interface{}) error {
+	beforeJSON, err := auditSnapshot(before)
+	if err != nil {
+		return err
+	}
+
+	afterJSON, err := auditSnapshot(after)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO audit_log (entity, entity_id, action, actor_id, before, after)
+				VALUES ($1, $2, $3, $4, $5::jsonb, $6::jsonb)`
+
+	_, err = tx.ExecContext(ctx, query, entity, entityID, action, actorID, beforeJSON, afterJSON)
+	return err
+}
+
+// auditSnapshot encodes v as JSON, returning a nil string pointer (SQL NULL)
+// for a nil snapshot.
+func auditSnapshot(v interface{}) (*string, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := string(data)
+	return &snapshot, nil
+}
+
+func scanAuditEntry(row rowScanner) (*models.AuditEntry, error) {
+	var entry models.AuditEntry
+	var before, after []byte
+	err := row.Scan(
+		&entry.ID,
+		&entry.Entity,
+		&entry.EntityID,
+		&entry.Action,
+		&entry.ActorID,
+		&entry.ActorUsername,
+		&before,
+		&after,
+		&entry.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	entry.Before = before
+	entry.After = after
+	return &entry, nil
+}
+
+// auditHistory lists the entries of one entity, oldest first.
+func auditHistory(ctx context.Context, db DBTX, entity string, entityID uuid.UUID) ([]*models.AuditEntry, error) {
+	query := `SELECT ` + auditColumns + ` FROM audit_log a
+				LEFT JOIN users u ON u.id = a.actor_id
+				WHERE a.entity = $1 AND a.entity_id = $2
+				ORDER BY a.id`
+
+	rows, err := db.QueryContext(ctx, query, entity, entityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*models.AuditEntry
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"pencatatan/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CustomerRepository interface {
+	Create(ctx context.Context, customer *models.CreateCustomerRequest) (*models.Customer, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Customer, error)
+	GetAll(ctx context.Context, q string) ([]*models.Customer, error)
+	Update(ctx context.Context, id uuid.UUID, customer *models.UpdateCustomerRequest) (*models.Customer, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	Lock(ctx context.Context, id uuid.UUID) error
+}
+
+type customerRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+func NewCustomerRepository(db *sql.DB, timeout time.Duration) CustomerRepository {
+	return &customerRepository{
+		db:      db,
+		timeout: timeout,
+	}
+}
+
+const customerColumns = `id, name, phone, address, notes, credit_limit, created_at, updated_at`
+
+func scanCustomer(row rowScanner) (*models.Customer, error) {
+	var customer models.Customer
+	err := row.Scan(
+		&customer.ID,
+		&customer.Name,
+		&customer.Phone,
+		&customer.Address,
+		&customer.Notes,
+		&customer.CreditLimit,
+		&customer.CreatedAt,
+		&customer.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &customer, nil
+}
+
+func (r *customerRepository) Create(ctx context.Context, customerReq *models.CreateCustomerRequest) (*models.Customer, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `INSERT INTO customers (name, phone, address, notes, credit_limit)
+				VALUES ($1, $2, $3, $4, $5)
+				RETURNING ` + customerColumns
+
+	return scanCustomer(conn(ctx, r.db).QueryRowContext(ctx,
+		query,
+		customerReq.Name,
+		customerReq.Phone,
+		customerReq.Address,
+		customerReq.Notes,
+		customerReq.CreditLimit,
+	))
+}
+
+func (r *customerRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Customer, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `SELECT ` + customerColumns + ` FROM customers WHERE id = $1`
+
+	customer, err := scanCustomer(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return customer, nil
+}
+
+// GetAll lists customers ordered by name, optionally filtered by a partial
+// name or phone number.
+func (r *customerRepository) GetAll(ctx context.Context, q string) ([]*models.Customer, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `SELECT ` + customerColumns + ` FROM customers
+				WHERE $1 = '' OR name ILIKE '%' || $1 || '%' OR phone ILIKE '%' || $1 || '%'
+				ORDER BY lower(name)`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, escapeLike(q))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var customers []*models.Customer
+	for rows.Next() {
+		customer, err := scanCustomer(rows)
+		if err != nil {
+			return nil, err
+		}
+		customers = append(customers, customer)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return customers, nil
+}
+
+func (r *customerRepository) Update(ctx context.Context, id uuid.UUID, customerReq *models.UpdateCustomerRequest) (*models.Customer, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+        UPDATE customers
+        SET name = COALESCE($1, name),
+            phone = COALESCE($2, phone),
+            address = COALESCE($3, address),
+            notes = COALESCE($4, notes),
+            credit_limit = COALESCE($5, credit_limit),
+            updated_at = NOW()
+        WHERE id = $6
+        RETURNING ` + customerColumns
+
+	customer, err := scanCustomer(conn(ctx, r.db).QueryRowContext(ctx,
+		query,
+		customerReq.Name,
+		customerReq.Phone,
+		customerReq.Address,
+		customerReq.Notes,
+		customerReq.CreditLimit,
+		id,
+	))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return customer, nil
+}
+
+func (r *customerRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `DELETE FROM customers WHERE id = $1`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Lock holds the customer's row until the transaction from TxManager.WithTx
+// ends, so checks on the customer's balance are not raced by other sales.
+// Outside a transaction the lock is released right away.
+func (r *customerRepository) Lock(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var locked uuid.UUID
+	return conn(ctx, r.db).QueryRowContext(ctx, `SELECT id FROM customers WHERE id = $1 FOR UPDATE`, id).Scan(&locked)
+}
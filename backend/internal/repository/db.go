@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrDuplicate is returned when an insert or update violates a unique index.
+var ErrDuplicate = errors.New("duplicate record")
+
+// withTimeout bounds a repository call by the configured query timeout. A
+// timeout of zero leaves ctx as it is. Calls that run out of time are logged
+// when cancelled, with the ID of the request they belong to.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, func() {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			slog.WarnContext(ctx, "database call timed out", "timeout", timeout)
+		}
+		cancel()
+	}
+}
+
+// translateError maps Postgres constraint errors to repository errors the
+// service layer can act on.
+func translateError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrDuplicate
+	}
+	return err
+}
@@ -0,0 +1,329 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"pencatatan/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNoOutstandingDebt  = errors.New("no outstanding debt")
+	ErrExceedsOutstanding = errors.New("payment exceeds outstanding debt")
+)
+
+type DebtRepository interface {
+	PaySale(ctx context.Context, saleID uuid.UUID, payment *models.CreateDebtPaymentRequest) (*models.DebtPayment, error)
+	PayCustomer(ctx context.Context, customerID uuid.UUID, payment *models.CreateDebtPaymentRequest) ([]*models.DebtPayment, error)
+	GetSaleDebt(ctx context.Context, saleID uuid.UUID) (*models.SaleDebt, error)
+	GetPayments(ctx context.Context, saleID uuid.UUID) ([]*models.DebtPayment, error)
+	GetCustomerBalance(ctx context.Context, customerID uuid.UUID) (*models.CustomerBalance, error)
+	GetOpenDebts(ctx context.Context, customerID *uuid.UUID) ([]*models.SaleDebt, error)
+}
+
+type debtRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+func NewDebtRepository(db *sql.DB, timeout time.Duration) DebtRepository {
+	return &debtRepository{
+		db:      db,
+		timeout: timeout,
+	}
+}
+
+const saleDebtColumns = `sale_id, customer_id, name, transaction_date, total, amount_received,
+	debt_amount, paid_amount, outstanding`
+
+const debtPaymentColumns = `id, sale_id, customer_id, amount, paid_at, note, created_at`
+
+func scanSaleDebt(row rowScanner) (*models.SaleDebt, error) {
+	var debt models.SaleDebt
+	err := row.Scan(
+		&debt.SaleID,
+		&debt.CustomerID,
+		&debt.Name,
+		&debt.TransactionDate,
+		&debt.Total,
+		&debt.AmountReceived,
+		&debt.DebtAmount,
+		&debt.PaidAmount,
+		&debt.Outstanding,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &debt, nil
+}
+
+func scanDebtPayment(row rowScanner) (*models.DebtPayment, error) {
+	var payment models.DebtPayment
+	err := row.Scan(
+		&payment.ID,
+		&payment.SaleID,
+		&payment.CustomerID,
+		&payment.Amount,
+		&payment.PaidAt,
+		&payment.Note,
+		&payment.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// PaySale records a repayment against one sale. The sale row is locked so
+// concurrent payments cannot both pass the outstanding check, and the sale
+// stops being a debt once it is fully settled.
+func (r *debtRepository) PaySale(ctx context.Context, saleID uuid.UUID, paymentReq *models.CreateDebtPaymentRequest) (*models.DebtPayment, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var customerID *uuid.UUID
+	err = tx.QueryRowContext(ctx, `SELECT customer_id FROM sales WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, saleID).Scan(&customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	var outstanding models.Money
+	err = tx.QueryRowContext(ctx, `SELECT outstanding FROM sale_debts WHERE sale_id = $1`, saleID).Scan(&outstanding)
+	if err != nil {
+		return nil, err
+	}
+
+	if outstanding <= 0 {
+		return nil, ErrNoOutstandingDebt
+	}
+	if paymentReq.Amount > outstanding {
+		return nil, ErrExceedsOutstanding
+	}
+
+	payment, err := insertDebtPayment(ctx, tx, saleID, customerID, paymentReq.Amount, paymentReq)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := settleIfPaid(ctx, tx, saleID, outstanding-paymentReq.Amount); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// PayCustomer spreads a repayment over the customer's open debts, oldest
+// transaction first.
+func (r *debtRepository) PayCustomer(ctx context.Context, customerID uuid.UUID, paymentReq *models.CreateDebtPaymentRequest) ([]*models.DebtPayment, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `SELECT id FROM sales WHERE customer_id = $1 AND is_debt AND deleted_at IS NULL FOR UPDATE`, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT sale_id, outstanding FROM sale_debts
+				WHERE customer_id = $1 AND outstanding > 0
+				ORDER BY transaction_date, sale_id`, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	type openDebt struct {
+		saleID      uuid.UUID
+		outstanding models.Money
+	}
+	var debts []openDebt
+	var totalOutstanding models.Money
+	for rows.Next() {
+		var debt openDebt
+		if err := rows.Scan(&debt.saleID, &debt.outstanding); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		debts = append(debts, debt)
+		totalOutstanding += debt.outstanding
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if totalOutstanding <= 0 {
+		return nil, ErrNoOutstandingDebt
+	}
+	if paymentReq.Amount > totalOutstanding {
+		return nil, ErrExceedsOutstanding
+	}
+
+	var payments []*models.DebtPayment
+	remaining := paymentReq.Amount
+	for _, debt := range debts {
+		if remaining <= 0 {
+			break
+		}
+
+		amount := min(remaining, debt.outstanding)
+		payment, err := insertDebtPayment(ctx, tx, debt.saleID, &customerID, amount, paymentReq)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+
+		if err := settleIfPaid(ctx, tx, debt.saleID, debt.outstanding-amount); err != nil {
+			return nil, err
+		}
+		remaining -= amount
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return payments, nil
+}
+
+func insertDebtPayment(ctx context.Context, tx DBTX, saleID uuid.UUID, customerID *uuid.UUID, amount models.Money, paymentReq *models.CreateDebtPaymentRequest) (*models.DebtPayment, error) {
+	paidAt := time.Now()
+	if paymentReq.PaidAt != nil {
+		paidAt = *paymentReq.PaidAt
+	}
+
+	query := `INSERT INTO debt_payments (sale_id, customer_id, amount, paid_at, note)
+				VALUES ($1, $2, $3, $4, $5)
+				RETURNING ` + debtPaymentColumns
+
+	return scanDebtPayment(tx.QueryRowContext(ctx, query, saleID, customerID, amount, paidAt, paymentReq.Note))
+}
+
+func settleIfPaid(ctx context.Context, tx DBTX, saleID uuid.UUID, outstanding models.Money) error {
+	if outstanding > 0 {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, `UPDATE sales SET is_debt = false, version = version + 1, updated_at = NOW() WHERE id = $1`, saleID)
+	return err
+}
+
+func (r *debtRepository) GetSaleDebt(ctx context.Context, saleID uuid.UUID) (*models.SaleDebt, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `SELECT ` + saleDebtColumns + ` FROM sale_debts WHERE sale_id = $1`
+
+	debt, err := scanSaleDebt(conn(ctx, r.db).QueryRowContext(ctx, query, saleID))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return debt, nil
+}
+
+func (r *debtRepository) GetPayments(ctx context.Context, saleID uuid.UUID) ([]*models.DebtPayment, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `SELECT ` + debtPaymentColumns + ` FROM debt_payments WHERE sale_id = $1 ORDER BY paid_at, created_at`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, saleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payments []*models.DebtPayment
+	for rows.Next() {
+		payment, err := scanDebtPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return payments, nil
+}
+
+func (r *debtRepository) GetCustomerBalance(ctx context.Context, customerID uuid.UUID) (*models.CustomerBalance, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `SELECT COALESCE(SUM(debt_amount), 0),
+					 COALESCE(SUM(paid_amount), 0),
+					 COALESCE(SUM(outstanding), 0),
+					 COUNT(*) FILTER (WHERE outstanding > 0)
+				FROM sale_debts WHERE customer_id = $1`
+
+	balance := models.CustomerBalance{CustomerID: customerID}
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, customerID).Scan(
+		&balance.TotalDebt,
+		&balance.TotalPaid,
+		&balance.Outstanding,
+		&balance.OpenSales,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &balance, nil
+}
+
+// GetOpenDebts lists sales with an outstanding amount, oldest first,
+// optionally limited to one customer.
+func (r *debtRepository) GetOpenDebts(ctx context.Context, customerID *uuid.UUID) ([]*models.SaleDebt, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `SELECT ` + saleDebtColumns + ` FROM sale_debts
+				WHERE outstanding > 0 AND ($1::uuid IS NULL OR customer_id = $1)
+				ORDER BY transaction_date, sale_id`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var debts []*models.SaleDebt
+	for rows.Next() {
+		debt, err := scanSaleDebt(rows)
+		if err != nil {
+			return nil, err
+		}
+		debts = append(debts, debt)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return debts, nil
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"pencatatan/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrClaimLost is returned when a request no longer holds the idempotency key
+// it claimed: its lease ran out and another request took the key over.
+var ErrClaimLost = errors.New("idempotency key claim lost")
+
+type IdempotencyRepository interface {
+	Claim(ctx context.Context, userID uuid.UUID, key, requestHash string, token uuid.UUID, expiresAt time.Time) (bool, error)
+	Get(ctx context.Context, userID uuid.UUID, key string) (*models.IdempotencyKey, error)
+	Renew(ctx context.Context, userID uuid.UUID, key string, token uuid.UUID, expiresAt time.Time) error
+	Complete(ctx context.Context, userID uuid.UUID, key string, token uuid.UUID, statusCode int, response []byte, expiresAt time.Time) error
+	Release(ctx context.Context, userID uuid.UUID, key string, token uuid.UUID) error
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+type idempotencyRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+func NewIdempotencyRepository(db *sql.DB, timeout time.Duration) IdempotencyRepository {
+	return &idempotencyRepository{
+		db:      db,
+		timeout: timeout,
+	}
+}
+
+const idempotencyKeyColumns = `user_id, key, request_hash, claim_token, status_code, response, created_at, expires_at`
+
+func scanIdempotencyKey(row rowScanner) (*models.IdempotencyKey, error) {
+	var record models.IdempotencyKey
+	err := row.Scan(
+		&record.UserID,
+		&record.Key,
+		&record.RequestHash,
+		&record.ClaimToken,
+		&record.StatusCode,
+		&record.Response,
+		&record.CreatedAt,
+		&record.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Claim takes the key for a new request, identified by token, until
+// expiresAt. It reports false when the key is still held; an expired key,
+// including one whose request never finished, is taken over. The insert is a
+// single statement, so of two requests racing for the same key exactly one
+// claims it.
+func (r *idempotencyRepository) Claim(ctx context.Context, userID uuid.UUID, key, requestHash string, token uuid.UUID, expiresAt time.Time) (bool, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `INSERT INTO idempotency_keys (user_id, key, request_hash, claim_token, expires_at)
+				VALUES ($1, $2, $3, $4, $5)
+				ON CONFLICT (user_id, key) DO UPDATE
+				SET request_hash = EXCLUDED.request_hash,
+					claim_token = EXCLUDED.claim_token,
+					status_code = NULL,
+					response = NULL,
+					created_at = NOW(),
+					expires_at = EXCLUDED.expires_at
+				WHERE idempotency_keys.expires_at <= NOW()`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, userID, key, requestHash, token, expiresAt)
+	if err != nil {
+		return false, err
+	}
+
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return claimed == 1, nil
+}
+
+func (r *idempotencyRepository) Get(ctx context.Context, userID uuid.UUID, key string) (*models.IdempotencyKey, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `SELECT ` + idempotencyKeyColumns + ` FROM idempotency_keys WHERE user_id = $1 AND key = $2`
+
+	record, err := scanIdempotencyKey(conn(ctx, r.db).QueryRowContext(ctx, query, userID, key))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// Renew moves the expiry of an unfinished claim held by token to expiresAt.
+func (r *idempotencyRepository) Renew(ctx context.Context, userID uuid.UUID, key string, token uuid.UUID, expiresAt time.Time) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `UPDATE idempotency_keys SET expires_at = $4
+				WHERE user_id = $1 AND key = $2 AND claim_token = $3 AND status_code IS NULL`
+
+	return r.execClaim(ctx, query, userID, key, token, expiresAt)
+}
+
+// Complete stores the response of the request holding token and keeps it
+// until expiresAt.
+func (r *idempotencyRepository) Complete(ctx context.Context, userID uuid.UUID, key string, token uuid.UUID, statusCode int, response []byte, expiresAt time.Time) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `UPDATE idempotency_keys SET status_code = $4, response = $5, expires_at = $6
+				WHERE user_id = $1 AND key = $2 AND claim_token = $3 AND status_code IS NULL`
+
+	return r.execClaim(ctx, query, userID, key, token, statusCode, response, expiresAt)
+}
+
+// execClaim runs a statement on the claim held by token and returns
+// ErrClaimLost when the key is no longer held by it.
+func (r *idempotencyRepository) execClaim(ctx context.Context, query string, args ...interface{}) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if updated == 0 {
+		return ErrClaimLost
+	}
+	return nil
+}
+
+// Release frees a key whose request did not finish, so it can be retried.
+// Keys with a stored response, or taken over by another request, are kept.
+func (r *idempotencyRepository) Release(ctx context.Context, userID uuid.UUID, key string, token uuid.UUID) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND claim_token = $3 AND status_code IS NULL`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, userID, key, token)
+	return err
+}
+
+func (r *idempotencyRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
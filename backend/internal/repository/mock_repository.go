@@ -1,51 +1,398 @@
 package repository
 
 import (
+	"context"
 	"pencatatan/internal/models"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 // MockSaleRepository is a mock implementation of SaleRepository for testing
 type MockSaleRepository struct {
-	CreateFunc  func(sale *models.CreateSalesRequest) (*models.Sale, error)
-	GetByIDFunc func(id uuid.UUID) (*models.Sale, error)
-	GetAllFunc  func() ([]*models.Sale, error)
-	UpdateFunc  func(id uuid.UUID, sale *models.UpdateSaleRequest) (*models.Sale, error)
-	DeleteFunc  func(id uuid.UUID) error
+	CreateFunc      func(ctx context.Context, sale *models.CreateSalesRequest) (*models.Sale, error)
+	CreateBatchFunc func(ctx context.Context, sales []*models.CreateSalesRequest) error
+	GetByIDFunc     func(ctx context.Context, id uuid.UUID) (*models.Sale, error)
+	LockFunc        func(ctx context.Context, id uuid.UUID) (*models.Sale, error)
+	GetAllFunc      func(ctx context.Context, filter *models.SaleFilter) ([]*models.Sale, int64, error)
+	SearchFunc      func(ctx context.Context, q string, limit int) ([]*models.SaleSearchResult, error)
+	StreamFunc      func(ctx context.Context, filter *models.SaleFilter, fn func(*models.SaleExportRow) error) error
+	UpdateFunc      func(ctx context.Context, id uuid.UUID, sale *models.UpdateSaleRequest, actorID *uuid.UUID) (*models.Sale, error)
+	DeleteFunc      func(ctx context.Context, id uuid.UUID, actorID *uuid.UUID) error
+	RestoreFunc     func(ctx context.Context, id uuid.UUID, actorID *uuid.UUID) (*models.Sale, error)
+	PurgeFunc       func(ctx context.Context, deletedBefore time.Time) (int64, error)
+	HistoryFunc     func(ctx context.Context, id uuid.UUID) ([]*models.AuditEntry, error)
 }
 
-func (m *MockSaleRepository) Create(sale *models.CreateSalesRequest) (*models.Sale, error) {
+func (m *MockSaleRepository) Create(ctx context.Context, sale *models.CreateSalesRequest) (*models.Sale, error) {
 	if m.CreateFunc != nil {
-		return m.CreateFunc(sale)
+		return m.CreateFunc(ctx, sale)
 	}
 	return nil, nil
 }
 
-func (m *MockSaleRepository) GetByID(id uuid.UUID) (*models.Sale, error) {
+func (m *MockSaleRepository) CreateBatch(ctx context.Context, sales []*models.CreateSalesRequest) error {
+	if m.CreateBatchFunc != nil {
+		return m.CreateBatchFunc(ctx, sales)
+	}
+	return nil
+}
+
+func (m *MockSaleRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Sale, error) {
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(ctx, id)
+	}
+	return nil, nil
+}
+
+func (m *MockSaleRepository) Lock(ctx context.Context, id uuid.UUID) (*models.Sale, error) {
+	if m.LockFunc != nil {
+		return m.LockFunc(ctx, id)
+	}
+	return nil, nil
+}
+
+func (m *MockSaleRepository) GetAll(ctx context.Context, filter *models.SaleFilter) ([]*models.Sale, int64, error) {
+	if m.GetAllFunc != nil {
+		return m.GetAllFunc(ctx, filter)
+	}
+	return nil, 0, nil
+}
+
+func (m *MockSaleRepository) Search(ctx context.Context, q string, limit int) ([]*models.SaleSearchResult, error) {
+	if m.SearchFunc != nil {
+		return m.SearchFunc(ctx, q, limit)
+	}
+	return nil, nil
+}
+
+func (m *MockSaleRepository) Stream(ctx context.Context, filter *models.SaleFilter, fn func(*models.SaleExportRow) error) error {
+	if m.StreamFunc != nil {
+		return m.StreamFunc(ctx, filter, fn)
+	}
+	return nil
+}
+
+func (m *MockSaleRepository) Update(ctx context.Context, id uuid.UUID, sale *models.UpdateSaleRequest, actorID *uuid.UUID) (*models.Sale, error) {
+	if m.UpdateFunc != nil {
+		return m.UpdateFunc(ctx, id, sale, actorID)
+	}
+	return nil, nil
+}
+
+func (m *MockSaleRepository) Delete(ctx context.Context, id uuid.UUID, actorID *uuid.UUID) error {
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(ctx, id, actorID)
+	}
+	return nil
+}
+
+func (m *MockSaleRepository) Restore(ctx context.Context, id uuid.UUID, actorID *uuid.UUID) (*models.Sale, error) {
+	if m.RestoreFunc != nil {
+		return m.RestoreFunc(ctx, id, actorID)
+	}
+	return nil, nil
+}
+
+func (m *MockSaleRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	if m.PurgeFunc != nil {
+		return m.PurgeFunc(ctx, deletedBefore)
+	}
+	return 0, nil
+}
+
+func (m *MockSaleRepository) History(ctx context.Context, id uuid.UUID) ([]*models.AuditEntry, error) {
+	if m.HistoryFunc != nil {
+		return m.HistoryFunc(ctx, id)
+	}
+	return nil, nil
+}
+
+// MockProductRepository is a mock implementation of ProductRepository for testing
+type MockProductRepository struct {
+	CreateFunc  func(ctx context.Context, product *models.CreateProductRequest) (*models.Product, error)
+	GetByIDFunc func(ctx context.Context, id uuid.UUID) (*models.Product, error)
+	GetAllFunc  func(ctx context.Context, activeOnly bool) ([]*models.Product, error)
+	UpdateFunc  func(ctx context.Context, id uuid.UUID, product *models.UpdateProductRequest) (*models.Product, error)
+	DeleteFunc  func(ctx context.Context, id uuid.UUID) error
+}
+
+func (m *MockProductRepository) Create(ctx context.Context, product *models.CreateProductRequest) (*models.Product, error) {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(ctx, product)
+	}
+	return nil, nil
+}
+
+func (m *MockProductRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Product, error) {
 	if m.GetByIDFunc != nil {
-		return m.GetByIDFunc(id)
+		return m.GetByIDFunc(ctx, id)
 	}
 	return nil, nil
 }
 
-func (m *MockSaleRepository) GetAll() ([]*models.Sale, error) {
+func (m *MockProductRepository) GetAll(ctx context.Context, activeOnly bool) ([]*models.Product, error) {
 	if m.GetAllFunc != nil {
-		return m.GetAllFunc()
+		return m.GetAllFunc(ctx, activeOnly)
 	}
 	return nil, nil
 }
 
-func (m *MockSaleRepository) Update(id uuid.UUID, sale *models.UpdateSaleRequest) (*models.Sale, error) {
+func (m *MockProductRepository) Update(ctx context.Context, id uuid.UUID, product *models.UpdateProductRequest) (*models.Product, error) {
 	if m.UpdateFunc != nil {
-		return m.UpdateFunc(id, sale)
+		return m.UpdateFunc(ctx, id, product)
 	}
 	return nil, nil
 }
 
-func (m *MockSaleRepository) Delete(id uuid.UUID) error {
+func (m *MockProductRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	if m.DeleteFunc != nil {
-		return m.DeleteFunc(id)
+		return m.DeleteFunc(ctx, id)
 	}
 	return nil
 }
+
+// MockCustomerRepository is a mock implementation of CustomerRepository for testing
+type MockCustomerRepository struct {
+	CreateFunc  func(ctx context.Context, customer *models.CreateCustomerRequest) (*models.Customer, error)
+	GetByIDFunc func(ctx context.Context, id uuid.UUID) (*models.Customer, error)
+	GetAllFunc  func(ctx context.Context, q string) ([]*models.Customer, error)
+	UpdateFunc  func(ctx context.Context, id uuid.UUID, customer *models.UpdateCustomerRequest) (*models.Customer, error)
+	DeleteFunc  func(ctx context.Context, id uuid.UUID) error
+	LockFunc    func(ctx context.Context, id uuid.UUID) error
+}
+
+func (m *MockCustomerRepository) Create(ctx context.Context, customer *models.CreateCustomerRequest) (*models.Customer, error) {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(ctx, customer)
+	}
+	return nil, nil
+}
+
+func (m *MockCustomerRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Customer, error) {
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(ctx, id)
+	}
+	return nil, nil
+}
+
+func (m *MockCustomerRepository) GetAll(ctx context.Context, q string) ([]*models.Customer, error) {
+	if m.GetAllFunc != nil {
+		return m.GetAllFunc(ctx, q)
+	}
+	return nil, nil
+}
+
+func (m *MockCustomerRepository) Update(ctx context.Context, id uuid.UUID, customer *models.UpdateCustomerRequest) (*models.Customer, error) {
+	if m.UpdateFunc != nil {
+		return m.UpdateFunc(ctx, id, customer)
+	}
+	return nil, nil
+}
+
+func (m *MockCustomerRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(ctx, id)
+	}
+	return nil
+}
+
+func (m *MockCustomerRepository) Lock(ctx context.Context, id uuid.UUID) error {
+	if m.LockFunc != nil {
+		return m.LockFunc(ctx, id)
+	}
+	return nil
+}
+
+// MockDebtRepository is a mock implementation of DebtRepository for testing
+type MockDebtRepository struct {
+	PaySaleFunc            func(ctx context.Context, saleID uuid.UUID, payment *models.CreateDebtPaymentRequest) (*models.DebtPayment, error)
+	PayCustomerFunc        func(ctx context.Context, customerID uuid.UUID, payment *models.CreateDebtPaymentRequest) ([]*models.DebtPayment, error)
+	GetSaleDebtFunc        func(ctx context.Context, saleID uuid.UUID) (*models.SaleDebt, error)
+	GetPaymentsFunc        func(ctx context.Context, saleID uuid.UUID) ([]*models.DebtPayment, error)
+	GetCustomerBalanceFunc func(ctx context.Context, customerID uuid.UUID) (*models.CustomerBalance, error)
+	GetOpenDebtsFunc       func(ctx context.Context, customerID *uuid.UUID) ([]*models.SaleDebt, error)
+}
+
+func (m *MockDebtRepository) PaySale(ctx context.Context, saleID uuid.UUID, payment *models.CreateDebtPaymentRequest) (*models.DebtPayment, error) {
+	if m.PaySaleFunc != nil {
+		return m.PaySaleFunc(ctx, saleID, payment)
+	}
+	return nil, nil
+}
+
+func (m *MockDebtRepository) PayCustomer(ctx context.Context, customerID uuid.UUID, payment *models.CreateDebtPaymentRequest) ([]*models.DebtPayment, error) {
+	if m.PayCustomerFunc != nil {
+		return m.PayCustomerFunc(ctx, customerID, payment)
+	}
+	return nil, nil
+}
+
+func (m *MockDebtRepository) GetSaleDebt(ctx context.Context, saleID uuid.UUID) (*models.SaleDebt, error) {
+	if m.GetSaleDebtFunc != nil {
+		return m.GetSaleDebtFunc(ctx, saleID)
+	}
+	return nil, nil
+}
+
+func (m *MockDebtRepository) GetPayments(ctx context.Context, saleID uuid.UUID) ([]*models.DebtPayment, error) {
+	if m.GetPaymentsFunc != nil {
+		return m.GetPaymentsFunc(ctx, saleID)
+	}
+	return nil, nil
+}
+
+func (m *MockDebtRepository) GetCustomerBalance(ctx context.Context, customerID uuid.UUID) (*models.CustomerBalance, error) {
+	if m.GetCustomerBalanceFunc != nil {
+		return m.GetCustomerBalanceFunc(ctx, customerID)
+	}
+	return &models.CustomerBalance{CustomerID: customerID}, nil
+}
+
+func (m *MockDebtRepository) GetOpenDebts(ctx context.Context, customerID *uuid.UUID) ([]*models.SaleDebt, error) {
+	if m.GetOpenDebtsFunc != nil {
+		return m.GetOpenDebtsFunc(ctx, customerID)
+	}
+	return nil, nil
+}
+
+// MockReportRepository is a mock implementation of ReportRepository for testing
+type MockReportRepository struct {
+	DebtAgingFunc    func(ctx context.Context) ([]*models.DebtAgingRow, error)
+	SalesSummaryFunc func(ctx context.Context, filter *models.SalesSummaryFilter) ([]*models.SalesSummaryBucket, error)
+}
+
+func (m *MockReportRepository) DebtAging(ctx context.Context) ([]*models.DebtAgingRow, error) {
+	if m.DebtAgingFunc != nil {
+		return m.DebtAgingFunc(ctx)
+	}
+	return nil, nil
+}
+
+func (m *MockReportRepository) SalesSummary(ctx context.Context, filter *models.SalesSummaryFilter) ([]*models.SalesSummaryBucket, error) {
+	if m.SalesSummaryFunc != nil {
+		return m.SalesSummaryFunc(ctx, filter)
+	}
+	return nil, nil
+}
+
+// MockUserRepository is a mock implementation of UserRepository for testing
+type MockUserRepository struct {
+	CreateFunc        func(ctx context.Context, user *models.CreateUserRequest, passwordHash string) (*models.User, error)
+	GetByIDFunc       func(ctx context.Context, id uuid.UUID) (*models.User, error)
+	GetByUsernameFunc func(ctx context.Context, username string) (*models.User, error)
+}
+
+func (m *MockUserRepository) Create(ctx context.Context, user *models.CreateUserRequest, passwordHash string) (*models.User, error) {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(ctx, user, passwordHash)
+	}
+	return nil, nil
+}
+
+func (m *MockUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(ctx, id)
+	}
+	return nil, nil
+}
+
+func (m *MockUserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	if m.GetByUsernameFunc != nil {
+		return m.GetByUsernameFunc(ctx, username)
+	}
+	return nil, nil
+}
+
+// MockRefreshTokenRepository is a mock implementation of RefreshTokenRepository for testing
+type MockRefreshTokenRepository struct {
+	CreateFunc  func(ctx context.Context, userID uuid.UUID, tokenHash string, expiresAt time.Time) (*models.RefreshToken, error)
+	ConsumeFunc func(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	RevokeFunc  func(ctx context.Context, tokenHash string) error
+}
+
+func (m *MockRefreshTokenRepository) Create(ctx context.Context, userID uuid.UUID, tokenHash string, expiresAt time.Time) (*models.RefreshToken, error) {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(ctx, userID, tokenHash, expiresAt)
+	}
+	return nil, nil
+}
+
+func (m *MockRefreshTokenRepository) Consume(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	if m.ConsumeFunc != nil {
+		return m.ConsumeFunc(ctx, tokenHash)
+	}
+	return nil, nil
+}
+
+func (m *MockRefreshTokenRepository) Revoke(ctx context.Context, tokenHash string) error {
+	if m.RevokeFunc != nil {
+		return m.RevokeFunc(ctx, tokenHash)
+	}
+	return nil
+}
+
+// MockIdempotencyRepository is a mock implementation of IdempotencyRepository for testing
+type MockIdempotencyRepository struct {
+	ClaimFunc         func(ctx context.Context, userID uuid.UUID, key, requestHash string, token uuid.UUID, expiresAt time.Time) (bool, error)
+	GetFunc           func(ctx context.Context, userID uuid.UUID, key string) (*models.IdempotencyKey, error)
+	RenewFunc         func(ctx context.Context, userID uuid.UUID, key string, token uuid.UUID, expiresAt time.Time) error
+	CompleteFunc      func(ctx context.Context, userID uuid.UUID, key string, token uuid.UUID, statusCode int, response []byte, expiresAt time.Time) error
+	ReleaseFunc       func(ctx context.Context, userID uuid.UUID, key string, token uuid.UUID) error
+	DeleteExpiredFunc func(ctx context.Context) (int64, error)
+}
+
+func (m *MockIdempotencyRepository) Claim(ctx context.Context, userID uuid.UUID, key, requestHash string, token uuid.UUID, expiresAt time.Time) (bool, error) {
+	if m.ClaimFunc != nil {
+		return m.ClaimFunc(ctx, userID, key, requestHash, token, expiresAt)
+	}
+	return true, nil
+}
+
+func (m *MockIdempotencyRepository) Get(ctx context.Context, userID uuid.UUID, key string) (*models.IdempotencyKey, error) {
+	if m.GetFunc != nil {
+		return m.GetFunc(ctx, userID, key)
+	}
+	return nil, nil
+}
+
+func (m *MockIdempotencyRepository) Renew(ctx context.Context, userID uuid.UUID, key string, token uuid.UUID, expiresAt time.Time) error {
+	if m.RenewFunc != nil {
+		return m.RenewFunc(ctx, userID, key, token, expiresAt)
+	}
+	return nil
+}
+
+func (m *MockIdempotencyRepository) Complete(ctx context.Context, userID uuid.UUID, key string, token uuid.UUID, statusCode int, response []byte, expiresAt time.Time) error {
+	if m.CompleteFunc != nil {
+		return m.CompleteFunc(ctx, userID, key, token, statusCode, response, expiresAt)
+	}
+	return nil
+}
+
+func (m *MockIdempotencyRepository) Release(ctx context.Context, userID uuid.UUID, key string, token uuid.UUID) error {
+	if m.ReleaseFunc != nil {
+		return m.ReleaseFunc(ctx, userID, key, token)
+	}
+	return nil
+}
+
+func (m *MockIdempotencyRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	if m.DeleteExpiredFunc != nil {
+		return m.DeleteExpiredFunc(ctx)
+	}
+	return 0, nil
+}
+
+// MockTxManager is a mock implementation of TxManager for testing. By default
+// it calls fn without a transaction.
+type MockTxManager struct {
+	WithTxFunc func(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+func (m *MockTxManager) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if m.WithTxFunc != nil {
+		return m.WithTxFunc(ctx, fn)
+	}
+	return fn(ctx)
+}
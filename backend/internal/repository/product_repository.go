@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"pencatatan/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ProductRepository interface {
+	Create(ctx context.Context, product *models.CreateProductRequest) (*models.Product, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Product, error)
+	GetAll(ctx context.Context, activeOnly bool) ([]*models.Product, error)
+	Update(ctx context.Context, id uuid.UUID, product *models.UpdateProductRequest) (*models.Product, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type productRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+func NewProductRepository(db *sql.DB, timeout time.Duration) ProductRepository {
+	return &productRepository{
+		db:      db,
+		timeout: timeout,
+	}
+}
+
+func (r *productRepository) Create(ctx context.Context, productReq *models.CreateProductRequest) (*models.Product, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `INSERT INTO products (sku, name, unit, price, cost_price, is_active)
+				VALUES ($1, $2, $3, $4, $5, $6)
+				RETURNING id, sku, name, unit, price, cost_price, is_active, created_at, updated_at`
+
+	var product models.Product
+	err := conn(ctx, r.db).QueryRowContext(ctx,
+		query,
+		productReq.SKU,
+		productReq.Name,
+		productReq.Unit,
+		productReq.Price,
+		productReq.CostPrice,
+		productReq.IsActive,
+	).Scan(
+		&product.ID,
+		&product.SKU,
+		&product.Name,
+		&product.Unit,
+		&product.Price,
+		&product.CostPrice,
+		&product.IsActive,
+		&product.CreatedAt,
+		&product.UpdatedAt,
+	)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &product, nil
+}
+
+func (r *productRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Product, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `SELECT id, sku, name, unit, price, cost_price, is_active, created_at, updated_at
+				FROM products WHERE id = $1`
+
+	var product models.Product
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
+		&product.ID,
+		&product.SKU,
+		&product.Name,
+		&product.Unit,
+		&product.Price,
+		&product.CostPrice,
+		&product.IsActive,
+		&product.CreatedAt,
+		&product.UpdatedAt,
+	)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &product, nil
+}
+
+func (r *productRepository) GetAll(ctx context.Context, activeOnly bool) ([]*models.Product, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `SELECT id, sku, name, unit, price, cost_price, is_active, created_at, updated_at
+				FROM products WHERE is_active OR NOT $1 ORDER BY name`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []*models.Product
+	for rows.Next() {
+		var product models.Product
+		err = rows.Scan(
+			&product.ID,
+			&product.SKU,
+			&product.Name,
+			&product.Unit,
+			&product.Price,
+			&product.CostPrice,
+			&product.IsActive,
+			&product.CreatedAt,
+			&product.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, &product)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+func (r *productRepository) Update(ctx context.Context, id uuid.UUID, productReq *models.UpdateProductRequest) (*models.Product, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+        UPDATE products
+        SET sku = COALESCE($1, sku),
+            name = COALESCE($2, name),
+            unit = COALESCE($3, unit),
+            price = COALESCE($4, price),
+            cost_price = COALESCE($5, cost_price),
+            is_active = COALESCE($6, is_active),
+            updated_at = NOW()
+        WHERE id = $7
+        RETURNING id, sku, name, unit, price, cost_price, is_active, created_at, updated_at
+    `
+
+	var product models.Product
+	err := conn(ctx, r.db).QueryRowContext(ctx,
+		query,
+		productReq.SKU,
+		productReq.Name,
+		productReq.Unit,
+		productReq.Price,
+		productReq.CostPrice,
+		productReq.IsActive,
+		id,
+	).Scan(
+		&product.ID,
+		&product.SKU,
+		&product.Name,
+		&product.Unit,
+		&product.Price,
+		&product.CostPrice,
+		&product.IsActive,
+		&product.CreatedAt,
+		&product.UpdatedAt,
+	)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &product, nil
+}
+
+func (r *productRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `DELETE FROM products WHERE id = $1`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
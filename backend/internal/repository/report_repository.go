@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"pencatatan/internal/models"
+	"time"
+)
+
+type ReportRepository interface {
+	DebtAging(ctx context.Context) ([]*models.DebtAgingRow, error)
+	SalesSummary(ctx context.Context, filter *models.SalesSummaryFilter) ([]*models.SalesSummaryBucket, error)
+}
+
+type reportRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+func NewReportRepository(db *sql.DB, timeout time.Duration) ReportRepository {
+	return &reportRepository{
+		db:      db,
+		timeout: timeout,
+	}
+}
+
+// DebtAging buckets every outstanding sale by its age in days and groups the
+// result per customer. Sales without a registered customer are grouped by
+// the free-text buyer name.
+func (r *reportRepository) DebtAging(ctx context.Context) ([]*models.DebtAgingRow, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+        WITH open_debts AS (
+            SELECT d.customer_id,
+                   COALESCE(c.name, NULLIF(trim(d.name), ''), 'Tanpa Nama') AS name,
+                   d.outstanding,
+                   CURRENT_DATE - d.transaction_date::date AS age
+            FROM sale_debts d
+            LEFT JOIN customers c ON c.id = d.customer_id
+            WHERE d.outstanding > 0
+        )
+        SELECT customer_id,
+               MIN(name) AS name,
+               COUNT(*) AS open_sales,
+               COALESCE(SUM(outstanding) FILTER (WHERE age <= 7), 0),
+               COALESCE(SUM(outstanding) FILTER (WHERE age BETWEEN 8 AND 30), 0),
+               COALESCE(SUM(outstanding) FILTER (WHERE age BETWEEN 31 AND 60), 0),
+               COALESCE(SUM(outstanding) FILTER (WHERE age > 60), 0),
+               SUM(outstanding) AS total
+        FROM open_debts
+        GROUP BY customer_id, CASE WHEN customer_id IS NULL THEN lower(name) END
+        ORDER BY COALESCE(SUM(outstanding) FILTER (WHERE age > 60), 0) DESC,
+                 COALESCE(SUM(outstanding) FILTER (WHERE age > 30), 0) DESC,
+                 total DESC
+    `
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var report []*models.DebtAgingRow
+	for rows.Next() {
+		var row models.DebtAgingRow
+		err := rows.Scan(
+			&row.CustomerID,
+			&row.Name,
+			&row.OpenSales,
+			&row.Days0To7,
+			&row.Days8To30,
+			&row.Days31To60,
+			&row.Over60Days,
+			&row.Total,
+		)
+		if err != nil {
+			return nil, err
+		}
+		report = append(report, &row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// SalesSummary returns one bucket per day, week or month between filter.From
+// and filter.To, including periods without any sales. Periods are truncated
+// in the business time zone so a sale at 23:30 local time counts for that
+// day and not the next one in UTC.
+func (r *reportRepository) SalesSummary(ctx context.Context, filter *models.SalesSummaryFilter) ([]*models.SalesSummaryBucket, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+        WITH periods AS (
+            SELECT generate_series(
+                       date_trunc($1::text, $2::timestamptz AT TIME ZONE $4::text),
+                       ($3::timestamptz AT TIME ZONE $4::text) - interval '1 microsecond',
+                       ('1 ' || $1::text)::interval
+                   ) AS period
+        ),
+        sale_totals AS (
+            SELECT date_trunc($1::text, s.transaction_date AT TIME ZONE $4::text) AS period,
+                   COUNT(*) AS transactions,
+                   SUM(s.total) AS revenue,
+                   SUM(COALESCE(i.quantity, 0)) AS items_sold,
+                   SUM(GREATEST(s.total - s.amount_received, 0)) AS debt_issued,
+                   SUM(LEAST(s.amount_received, s.total)) AS cash_at_sale
+            FROM sales s
+            LEFT JOIN LATERAL (
+                SELECT SUM(quantity) AS quantity FROM sale_items WHERE sale_id = s.id
+            ) i ON true
+            WHERE s.transaction_date >= $2 AND s.transaction_date < $3
+              AND s.deleted_at IS NULL
+            GROUP BY 1
+        ),
+        repayments AS (
+            SELECT date_trunc($1::text, paid_at AT TIME ZONE $4::text) AS period,
+                   SUM(amount) AS amount
+            FROM debt_payments d
+            WHERE paid_at >= $2 AND paid_at < $3
+              AND EXISTS (SELECT 1 FROM sales WHERE id = d.sale_id AND deleted_at IS NULL)
+            GROUP BY 1
+        )
+        SELECT to_char(p.period, 'YYYY-MM-DD'),
+               COALESCE(s.revenue, 0),
+               COALESCE(s.transactions, 0),
+               COALESCE(s.items_sold, 0)::bigint,
+               COALESCE(s.debt_issued, 0),
+               COALESCE(s.cash_at_sale, 0) + COALESCE(r.amount, 0)
+        FROM periods p
+        LEFT JOIN sale_totals s ON s.period = p.period
+        LEFT JOIN repayments r ON r.period = p.period
+        ORDER BY p.period
+    `
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, filter.Granularity, filter.From, filter.To, filter.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var buckets []*models.SalesSummaryBucket
+	for rows.Next() {
+		var bucket models.SalesSummaryBucket
+		err := rows.Scan(
+			&bucket.Period,
+			&bucket.Revenue,
+			&bucket.Transactions,
+			&bucket.ItemsSold,
+			&bucket.DebtIssued,
+			&bucket.CashReceived,
+		)
+		if err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, &bucket)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return buckets, nil
+}
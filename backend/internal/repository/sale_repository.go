@@ -1,85 +1,227 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"html"
 	"pencatatan/internal/models"
+	"strings"
+	"time"
+	"unicode"
 
 	"github.com/google/uuid"
 )
 
 type SaleRepository interface {
-	Create(sale *models.CreateSalesRequest) (*models.Sale, error)
-	GetByID(id uuid.UUID) (*models.Sale, error)
-	GetAll() ([]*models.Sale, error)
-	Update(id uuid.UUID, sale *models.UpdateSaleRequest) (*models.Sale, error)
-	Delete(id uuid.UUID) error
+	Create(ctx context.Context, sale *models.CreateSalesRequest) (*models.Sale, error)
+	CreateBatch(ctx context.Context, sales []*models.CreateSalesRequest) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Sale, error)
+	Lock(ctx context.Context, id uuid.UUID) (*models.Sale, error)
+	GetAll(ctx context.Context, filter *models.SaleFilter) ([]*models.Sale, int64, error)
+	Search(ctx context.Context, q string, limit int) ([]*models.SaleSearchResult, error)
+	Stream(ctx context.Context, filter *models.SaleFilter, fn func(*models.SaleExportRow) error) error
+	Update(ctx context.Context, id uuid.UUID, sale *models.UpdateSaleRequest, actorID *uuid.UUID) (*models.Sale, error)
+	Delete(ctx context.Context, id uuid.UUID, actorID *uuid.UUID) error
+	Restore(ctx context.Context, id uuid.UUID, actorID *uuid.UUID) (*models.Sale, error)
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
+	History(ctx context.Context, id uuid.UUID) ([]*models.AuditEntry, error)
 }
 
 type saleRepository struct {
-	db *sql.DB
+	db      *sql.DB
+	timeout time.Duration
 }
 
-func NewSaleRepository(db *sql.DB) SaleRepository {
+func NewSaleRepository(db *sql.DB, timeout time.Duration) SaleRepository {
 	return &saleRepository{
-		db: db,
+		db:      db,
+		timeout: timeout,
 	}
 }
 
-func (r *saleRepository) Create(saleReq *models.CreateSalesRequest) (*models.Sale, error) {
-	query := `INSERT INTO sales (name, product, quantity, price, amount_received, is_debt) 
-				VALUES ($1, $2, $3, $4, $5, $6)
-				RETURNING id, name, product, quantity, price, total, amount_received, change_amount, transaction_date, is_debt,created_at, updated_at`
+const saleColumns = `id, name, customer_id, total, amount_received, change_amount, currency,
+	transaction_date, is_debt, created_by, version, created_at, updated_at, deleted_at`
+
+const saleItemColumns = `id, sale_id, product_id, product, quantity, price, subtotal`
+
+// ErrVersionMismatch is returned by Update when the sale has been changed since
+// the version the caller read.
+var ErrVersionMismatch = errors.New("sale version mismatch")
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
 
+// scanSale scans a row selected with saleColumns. Extra destinations are
+// filled from any columns selected after saleColumns.
+func scanSale(row rowScanner, extra ...interface{}) (*models.Sale, error) {
 	var sale models.Sale
-	err := r.db.QueryRow(
-		query,
-		saleReq.Name,
-		saleReq.Product,
-		saleReq.Quantity,
-		saleReq.Price,
-		saleReq.AmountReceived,
-		saleReq.IsDebt,
-	).Scan(
+	dest := []interface{}{
 		&sale.ID,
 		&sale.Name,
-		&sale.Product,
-		&sale.Quantity,
-		&sale.Price,
+		&sale.CustomerID,
 		&sale.Total,
 		&sale.AmountReceived,
 		&sale.ChangeAmount,
+		&sale.Currency,
 		&sale.TransactionDate,
 		&sale.IsDebt,
+		&sale.CreatedBy,
+		&sale.Version,
 		&sale.CreatedAt,
 		&sale.UpdatedAt,
+		&sale.DeletedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	return &sale, nil
+}
+
+func scanSaleItem(row rowScanner) (*models.SaleItem, error) {
+	var item models.SaleItem
+	err := row.Scan(
+		&item.ID,
+		&item.SaleID,
+		&item.ProductID,
+		&item.Product,
+		&item.Quantity,
+		&item.Price,
+		&item.Subtotal,
 	)
 	if err != nil {
 		return nil, err
 	}
+	return &item, nil
+}
 
-	return &sale, nil
+// Create writes the sale header and all of its items in one transaction.
+func (r *saleRepository) Create(ctx context.Context, saleReq *models.CreateSalesRequest) (*models.Sale, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	sale, err := insertSale(ctx, tx, saleReq)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return sale, nil
 }
 
-func (r *saleRepository) GetByID(id uuid.UUID) (*models.Sale, error) {
-	query := `SELECT id, name, product, quantity, price, total, amount_received, change_amount, transaction_date, is_debt,created_at, updated_at
-				FROM sales WHERE id = $1`
+// CreateBatch writes several sales in one transaction, so either all of them
+// are saved or none.
+func (r *saleRepository) CreateBatch(ctx context.Context, saleReqs []*models.CreateSalesRequest) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
 
-	var sale models.Sale
-	err := r.db.QueryRow(query, id).Scan(
-		&sale.ID,
-		&sale.Name,
-		&sale.Product,
-		&sale.Quantity,
-		&sale.Price,
-		&sale.Total,
-		&sale.AmountReceived,
-		&sale.ChangeAmount,
-		&sale.TransactionDate,
-		&sale.IsDebt,
-		&sale.CreatedAt,
-		&sale.UpdatedAt,
-	)
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, saleReq := range saleReqs {
+		if _, err := insertSale(ctx, tx, saleReq); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func insertSale(ctx context.Context, tx DBTX, saleReq *models.CreateSalesRequest) (*models.Sale, error) {
+	query := `INSERT INTO sales (name, customer_id, amount_received, currency, is_debt, transaction_date, created_by)
+				VALUES ($1, $2, $3, $4, $5, COALESCE($6::timestamptz, CURRENT_TIMESTAMP), $7)
+				RETURNING id`
+
+	var id uuid.UUID
+	err := tx.QueryRowContext(ctx,
+		query,
+		saleReq.Name,
+		saleReq.CustomerID,
+		saleReq.AmountReceived,
+		saleReq.Currency,
+		saleReq.IsDebt,
+		saleReq.TransactionDate,
+		saleReq.CreatedBy,
+	).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := insertSaleItems(ctx, tx, id, saleReq.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	sale, err := updateSaleTotal(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	sale.Items = items
+
+	if err := writeAudit(ctx, tx, models.AuditEntitySale, models.AuditActionCreate, sale.ID, saleReq.CreatedBy, nil, sale); err != nil {
+		return nil, err
+	}
+
+	return sale, nil
+}
+
+func insertSaleItems(ctx context.Context, tx DBTX, saleID uuid.UUID, itemReqs []models.SaleItemRequest) ([]*models.SaleItem, error) {
+	query := `INSERT INTO sale_items (sale_id, position, product_id, product, quantity, price)
+				VALUES ($1, $2, $3, $4, $5, $6)
+				RETURNING ` + saleItemColumns
+
+	items := make([]*models.SaleItem, 0, len(itemReqs))
+	for i, itemReq := range itemReqs {
+		item, err := scanSaleItem(tx.QueryRowContext(ctx,
+			query,
+			saleID,
+			i,
+			itemReq.ProductID,
+			itemReq.Product,
+			itemReq.Quantity,
+			itemReq.Price,
+		))
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// updateSaleTotal recomputes the header total from the sale's items.
+func updateSaleTotal(ctx context.Context, tx DBTX, saleID uuid.UUID) (*models.Sale, error) {
+	query := `UPDATE sales
+				SET total = (SELECT COALESCE(SUM(subtotal), 0) FROM sale_items WHERE sale_id = $1)
+				WHERE id = $1
+				RETURNING ` + saleColumns
+
+	return scanSale(tx.QueryRowContext(ctx, query, saleID))
+}
+
+// lockSale loads a sale with its items and locks the row for the rest of the
+// transaction. deleted selects a sale in the trash instead of a live one. It
+// returns (nil, nil) when there is no such sale.
+func lockSale(ctx context.Context, tx DBTX, id uuid.UUID, deleted bool) (*models.Sale, error) {
+	query := `SELECT ` + saleColumns + ` FROM sales WHERE id = $1 AND (deleted_at IS NOT NULL) = $2 FOR UPDATE`
+
+	sale, err := scanSale(tx.QueryRowContext(ctx, query, id, deleted))
 
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, nil
@@ -89,111 +231,529 @@ func (r *saleRepository) GetByID(id uuid.UUID) (*models.Sale, error) {
 		return nil, err
 	}
 
-	return &sale, nil
+	if err := attachItems(ctx, tx, sale); err != nil {
+		return nil, err
+	}
+
+	return sale, nil
 }
 
-func (r *saleRepository) GetAll() ([]*models.Sale, error) {
-	query := `SELECT id, name,product, quantity, price, total, amount_received, change_amount, transaction_date, is_debt,created_at, updated_at
-				FROM sales ORDER BY created_at DESC`
+// attachItems loads the items of all given sales with a single query.
+func attachItems(ctx context.Context, q DBTX, sales ...*models.Sale) error {
+	if len(sales) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(sales))
+	byID := make(map[uuid.UUID]*models.Sale, len(sales))
+	for i, sale := range sales {
+		ids[i] = sale.ID.String()
+		byID[sale.ID] = sale
+		sale.Items = []*models.SaleItem{}
+	}
+
+	query := `SELECT ` + saleItemColumns + ` FROM sale_items
+				WHERE sale_id = ANY($1::uuid[])
+				ORDER BY sale_id, position`
 
-	rows, err := r.db.Query(query)
+	rows, err := q.QueryContext(ctx, query, ids)
 	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item, err := scanSaleItem(rows)
+		if err != nil {
+			return err
+		}
+		if sale, ok := byID[item.SaleID]; ok {
+			sale.Items = append(sale.Items, item)
+		}
+	}
+
+	return rows.Err()
+}
+
+func (r *saleRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Sale, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `SELECT ` + saleColumns + ` FROM sales WHERE id = $1 AND deleted_at IS NULL`
+
+	sale, err := scanSale(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := attachItems(ctx, conn(ctx, r.db), sale); err != nil {
 		return nil, err
 	}
+
+	return sale, nil
+}
+
+// Lock loads a sale and holds its row until the transaction from
+// TxManager.WithTx ends, so the sale can be checked before it is changed.
+func (r *saleRepository) Lock(ctx context.Context, id uuid.UUID) (*models.Sale, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	return lockSale(ctx, conn(ctx, r.db), id, false)
+}
+
+type sortColumn struct {
+	name string
+	cast string
+}
+
+// saleSortColumns whitelists the columns GET /api/sales may be sorted by.
+var saleSortColumns = map[string]sortColumn{
+	"transaction_date": {"transaction_date", "timestamptz"},
+	"created_at":       {"created_at", "timestamptz"},
+	"total":            {"total", "numeric"},
+	"deleted_at":       {"deleted_at", "timestamptz"},
+}
+
+func (r *saleRepository) GetAll(ctx context.Context, filter *models.SaleFilter) ([]*models.Sale, int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	where, args := saleFilterConditions(filter)
+
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM sales` + where
+	if err := conn(ctx, r.db).QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	column, ok := saleSortColumns[filter.Sort]
+	if !ok {
+		column = saleSortColumns["transaction_date"]
+	}
+	direction, comparator := "ASC", ">"
+	if filter.Desc {
+		direction, comparator = "DESC", "<"
+	}
+
+	if filter.After != nil {
+		args = append(args, filter.After.Value, filter.After.ID)
+		where += fmt.Sprintf(" AND (%s, id) %s ($%d::%s, $%d::uuid)", column.name, comparator, len(args)-1, column.cast, len(args))
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := `SELECT ` + saleColumns + ` FROM sales` + where +
+		fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT $%d OFFSET $%d", column.name, direction, direction, len(args)-1, len(args))
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
 	defer rows.Close()
 
 	var sales []*models.Sale
 	for rows.Next() {
-		var sale models.Sale
-		err = rows.Scan(
-			&sale.ID,
-			&sale.Name,
-			&sale.Product,
-			&sale.Quantity,
-			&sale.Price,
-			&sale.Total,
-			&sale.AmountReceived,
-			&sale.ChangeAmount,
-			&sale.TransactionDate,
-			&sale.IsDebt,
-			&sale.CreatedAt,
-			&sale.UpdatedAt,
-		)
+		sale, err := scanSale(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		sales = append(sales, sale)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if err := attachItems(ctx, conn(ctx, r.db), sales...); err != nil {
+		return nil, 0, err
+	}
+
+	return sales, total, nil
+}
+
+// Stream calls fn for every sale matching the filter, oldest first. Rows are
+// read one at a time so large exports never sit in memory as a whole. The
+// query timeout does not apply: a large export is expected to outlast it, and
+// ctx still ends the query when the client goes away.
+func (r *saleRepository) Stream(ctx context.Context, filter *models.SaleFilter, fn func(*models.SaleExportRow) error) error {
+	where, args := saleFilterConditions(filter)
+
+	query := `SELECT ` + saleColumns + `,
+				   COALESCE(items.products, ''),
+				   COALESCE(items.quantity, 0)
+				FROM sales
+				LEFT JOIN LATERAL (
+				    SELECT string_agg(i.product || ' x' || i.quantity, ', ' ORDER BY i.position) AS products,
+				           SUM(i.quantity) AS quantity
+				    FROM sale_items i
+				    WHERE i.sale_id = sales.id
+				) items ON true` + where + `
+				ORDER BY transaction_date, id`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row models.SaleExportRow
+		sale, err := scanSale(rows, &row.Products, &row.ItemsSold)
+		if err != nil {
+			return err
+		}
+		row.Sale = *sale
+
+		if err := fn(&row); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// saleFilterConditions builds the WHERE clause shared by the count and the
+// page query. Values are always bound as parameters.
+func saleFilterConditions(filter *models.SaleFilter) (string, []interface{}) {
+	conditions := []string{"deleted_at IS NULL"}
+	if filter.Deleted {
+		conditions[0] = "deleted_at IS NOT NULL"
+	}
+	var args []interface{}
+
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.From != nil {
+		add("transaction_date >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		add("transaction_date < $%d", *filter.To)
+	}
+	if filter.IsDebt != nil {
+		add("is_debt = $%d", *filter.IsDebt)
+	}
+	if filter.Product != "" {
+		add("EXISTS (SELECT 1 FROM sale_items i WHERE i.sale_id = sales.id AND i.product ILIKE '%%' || $%d || '%%')", escapeLike(filter.Product))
+	}
+	if filter.Name != "" {
+		add("name ILIKE '%%' || $%d || '%%'", escapeLike(filter.Name))
+	}
+	if filter.CustomerID != nil {
+		add("customer_id = $%d", *filter.CustomerID)
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// Search matches customer names and the products on each receipt using the
+// full-text indexes with prefix terms, falling back to trigram similarity so
+// small typos still match.
+func (r *saleRepository) Search(ctx context.Context, q string, limit int) ([]*models.SaleSearchResult, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+        WITH search AS (
+            SELECT to_tsquery('simple', $1) AS tsq, $2::text AS raw,
+                   'StartSel=' || chr(2) || ', StopSel=' || chr(3) || ', HighlightAll=true' AS options
+        ),
+        matches AS (
+            SELECT s.id AS sale_id
+            FROM sales s, search
+            WHERE s.search_vector @@ search.tsq OR s.name % search.raw
+            UNION
+            SELECT i.sale_id
+            FROM sale_items i, search
+            WHERE i.search_vector @@ search.tsq OR i.product % search.raw
+        )
+        SELECT ` + saleColumns + `,
+               ts_rank(s.search_vector || setweight(to_tsvector('simple', items.products), 'B'), search.tsq)
+                   + GREATEST(similarity(coalesce(s.name, ''), search.raw), similarity(items.products, search.raw)) AS rank,
+               ts_headline('simple', coalesce(s.name, ''), search.tsq, search.options),
+               ts_headline('simple', items.products, search.tsq, search.options)
+        FROM sales s
+        JOIN matches m ON m.sale_id = s.id
+        CROSS JOIN search
+        CROSS JOIN LATERAL (
+            SELECT COALESCE(string_agg(i.product, ', ' ORDER BY i.position), '') AS products
+            FROM sale_items i
+            WHERE i.sale_id = s.id
+        ) items
+        WHERE s.deleted_at IS NULL
+        ORDER BY rank DESC, s.transaction_date DESC
+        LIMIT $3
+    `
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, prefixTSQuery(q), q, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*models.SaleSearchResult
+	var sales []*models.Sale
+	for rows.Next() {
+		var result models.SaleSearchResult
+		sale, err := scanSale(rows, &result.Rank, &result.Highlight.Name, &result.Highlight.Products)
 		if err != nil {
 			return nil, err
 		}
-		sales = append(sales, &sale)
+		result.Sale = *sale
+		result.Highlight.Name = highlightHTML(result.Highlight.Name)
+		result.Highlight.Products = highlightHTML(result.Highlight.Products)
+		results = append(results, &result)
+		sales = append(sales, &result.Sale)
 	}
 
 	if err = rows.Err(); err != nil {
 		return nil, err
 	}
 
-	return sales, nil
+	if err := attachItems(ctx, conn(ctx, r.db), sales...); err != nil {
+		return nil, err
+	}
+
+	return results, nil
 }
 
-func (r *saleRepository) Update(id uuid.UUID, saleReq *models.UpdateSaleRequest) (*models.Sale, error) {
-	query := `
-        UPDATE sales
-        SET name = COALESCE(NULLIF($1, ''), name),
-            product = COALESCE(NULLIF($2, ''), product),
-            quantity = COALESCE(NULLIF($3, 0), quantity),
-            price = COALESCE(NULLIF($4, 0), price),
-            amount_received = COALESCE(NULLIF($5, 0), amount_received),
-            is_debt = $6,
-            updated_at = NOW()
-        WHERE id = $7
-        RETURNING id, name,product, quantity, price, total, amount_received,
-                  change_amount, transaction_date, is_debt,created_at, updated_at
-    `
+// highlightMarks turns the control characters ts_headline puts around matches
+// into <mark> tags.
+var highlightMarks = strings.NewReplacer("\x02", "<mark>", "\x03", "</mark>")
 
-	var sale models.Sale
-	err := r.db.QueryRow(
-		query,
-		saleReq.Name,
-		saleReq.Product,
-		saleReq.Quantity,
-		saleReq.Price,
-		saleReq.AmountReceived,
-		saleReq.IsDebt,
-		id,
-	).Scan(
-		&sale.ID,
-		&sale.Name,
-		&sale.Product,
-		&sale.Quantity,
-		&sale.Price,
-		&sale.Total,
-		&sale.AmountReceived,
-		&sale.ChangeAmount,
-		&sale.TransactionDate,
-		&sale.IsDebt,
-		&sale.CreatedAt,
-		&sale.UpdatedAt,
-	)
+// highlightHTML escapes a headline from ts_headline, which copies the stored
+// text as is, and only then adds the <mark> tags, so a name like
+// <img onerror=...> is shown as text instead of run.
+func highlightHTML(headline string) string {
+	return highlightMarks.Replace(html.EscapeString(headline))
+}
+
+// prefixTSQuery turns free text such as "kopi su" into "kopi:* & su:*" so
+// partially typed words match. Anything but letters and digits is dropped,
+// which keeps user input from producing an invalid tsquery.
+func prefixTSQuery(q string) string {
+	words := strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := make([]string, len(words))
+	for i, word := range words {
+		terms[i] = word + ":*"
+	}
+
+	return strings.Join(terms, " & ")
+}
+
+// Update changes the sale header and, when items are given, replaces all of
+// its items in the same transaction. The sale before and after the change is
+// written to the audit log.
+func (r *saleRepository) Update(ctx context.Context, id uuid.UUID, saleReq *models.UpdateSaleRequest, actorID *uuid.UUID) (*models.Sale, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := begin(ctx, r.db)
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
 
-	return &sale, nil
+	before, err := lockSale(ctx, tx, id, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if before == nil {
+		return nil, nil
+	}
+
+	if saleReq.Version != models.AnyVersion && before.Version != saleReq.Version {
+		return nil, ErrVersionMismatch
+	}
+
+	sets, args := saleUpdateAssignments(saleReq)
+	args = append(args, id)
+	query := `UPDATE sales SET ` + strings.Join(sets, ", ") +
+		fmt.Sprintf(` WHERE id = $%d RETURNING `, len(args)) + saleColumns
+
+	sale, err := scanSale(tx.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		return nil, err
+	}
+
+	if saleReq.Items != nil {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM sale_items WHERE sale_id = $1`, id); err != nil {
+			return nil, err
+		}
+
+		if _, err := insertSaleItems(ctx, tx, id, saleReq.Items); err != nil {
+			return nil, err
+		}
+
+		if sale, err = updateSaleTotal(ctx, tx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := attachItems(ctx, tx, sale); err != nil {
+		return nil, err
+	}
+
+	if err := writeAudit(ctx, tx, models.AuditEntitySale, models.AuditActionUpdate, id, actorID, before, sale); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return sale, nil
 }
 
-func (r *saleRepository) Delete(id uuid.UUID) error {
-	query := `DELETE FROM sales WHERE id = $1`
+// saleUpdateAssignments returns the SET clauses and their arguments for the
+// fields present in the request. A null name is stored as empty, as on create.
+func saleUpdateAssignments(saleReq *models.UpdateSaleRequest) ([]string, []interface{}) {
+	var sets []string
+	var args []interface{}
+	set := func(column string, value interface{}) {
+		args = append(args, value)
+		sets = append(sets, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+
+	if saleReq.Name.Set {
+		set("name", saleReq.Name.Value)
+	}
+
+	if saleReq.CustomerID.Set {
+		if saleReq.CustomerID.Null {
+			set("customer_id", nil)
+		} else {
+			set("customer_id", saleReq.CustomerID.Value)
+		}
+	}
+
+	if saleReq.AmountReceived.Set {
+		set("amount_received", saleReq.AmountReceived.Value)
+	}
 
-	result, err := r.db.Exec(query, id)
+	if saleReq.IsDebt.Set {
+		set("is_debt", saleReq.IsDebt.Value)
+	}
+
+	return append(sets, "version = version + 1", "updated_at = NOW()"), args
+}
+
+// Delete moves the sale to the trash. It stays out of every list, report and
+// debt total until it is restored or purged.
+func (r *saleRepository) Delete(ctx context.Context, id uuid.UUID, actorID *uuid.UUID) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := begin(ctx, r.db)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	rowsAffected, err := result.RowsAffected()
+	before, err := lockSale(ctx, tx, id, false)
 	if err != nil {
 		return err
 	}
 
-	if rowsAffected == 0 {
+	if before == nil {
 		return sql.ErrNoRows
 	}
 
-	return nil
+	if _, err := tx.ExecContext(ctx, `UPDATE sales SET deleted_at = NOW(), version = version + 1 WHERE id = $1`, id); err != nil {
+		return err
+	}
+
+	if err := writeAudit(ctx, tx, models.AuditEntitySale, models.AuditActionDelete, id, actorID, before, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Restore takes a sale out of the trash. It returns (nil, nil) when the sale
+// is not in the trash.
+func (r *saleRepository) Restore(ctx context.Context, id uuid.UUID, actorID *uuid.UUID) (*models.Sale, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	before, err := lockSale(ctx, tx, id, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if before == nil {
+		return nil, nil
+	}
+
+	query := `UPDATE sales SET deleted_at = NULL, version = version + 1, updated_at = NOW() WHERE id = $1 RETURNING ` + saleColumns
+
+	sale, err := scanSale(tx.QueryRowContext(ctx, query, id))
+	if err != nil {
+		return nil, err
+	}
+	sale.Items = before.Items
+
+	if err := writeAudit(ctx, tx, models.AuditEntitySale, models.AuditActionRestore, id, actorID, before, sale); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return sale, nil
+}
+
+// Purge permanently removes sales that were moved to the trash before the
+// given time, together with their items and payments. Each purge is noted in
+// the audit log, which still holds the sale as it was when deleted.
+func (r *saleRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+        WITH purged AS (
+            DELETE FROM sales
+            WHERE deleted_at IS NOT NULL AND deleted_at < $1
+            RETURNING id
+        )
+        INSERT INTO audit_log (entity, entity_id, action)
+        SELECT $2, id, $3 FROM purged`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, deletedBefore, models.AuditEntitySale, models.AuditActionPurge)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// History lists the audit log entries of a sale, oldest first. Entries are
+// kept after the sale is deleted.
+func (r *saleRepository) History(ctx context.Context, id uuid.UUID) ([]*models.AuditEntry, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	return auditHistory(ctx, conn(ctx, r.db), models.AuditEntitySale, id)
 }
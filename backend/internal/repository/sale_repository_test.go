@@ -0,0 +1,17 @@
+package repository
+
+import "testing"
+
+func TestHighlightHTML(t *testing.T) {
+	tests := map[string]string{
+		"Bu \x02Sari\x03": "Bu <mark>Sari</mark>",
+		"<img src=x onerror=alert(1)> \x02kopi\x03": "&lt;img src=x onerror=alert(1)&gt; <mark>kopi</mark>",
+		`Tom & "Jerry"`: "Tom &amp; &#34;Jerry&#34;",
+	}
+
+	for headline, expected := range tests {
+		if got := highlightHTML(headline); got != expected {
+			t.Errorf("highlightHTML(%q) = %q, expected %q", headline, got, expected)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"pencatatan/internal/models"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+func newMockDB(t *testing.T) (*txManager, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to open sqlmock: %v", err)
+	}
+	return &txManager{db: db}, mock, func() { db.Close() }
+}
+
+func TestWithTx_CommitsOnSuccess(t *testing.T) {
+	manager, mock, closeDB := newMockDB(t)
+	defer closeDB()
+	customers := NewCustomerRepository(manager.db, time.Second)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM customers WHERE id = \$1 FOR UPDATE`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	mock.ExpectCommit()
+
+	err := manager.WithTx(context.Background(), func(ctx context.Context) error {
+		return customers.Lock(ctx, uuid.New())
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWithTx_RollsBackOnError(t *testing.T) {
+	manager, mock, closeDB := newMockDB(t)
+	defer closeDB()
+	customers := NewCustomerRepository(manager.db, time.Second)
+	failed := errors.New("credit limit exceeded")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM customers WHERE id = \$1 FOR UPDATE`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	mock.ExpectRollback()
+
+	err := manager.WithTx(context.Background(), func(ctx context.Context) error {
+		if err := customers.Lock(ctx, uuid.New()); err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Errorf("Expected the error from fn, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWithTx_RollsBackOnPanic(t *testing.T) {
+	manager, mock, closeDB := newMockDB(t)
+	defer closeDB()
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	defer func() {
+		if p := recover(); p != "boom" {
+			t.Errorf("Expected the panic to be re-raised, got %v", p)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	_ = manager.WithTx(context.Background(), func(ctx context.Context) error {
+		panic("boom")
+	})
+}
+
+// A repository method with its own transaction joins the outer one: it
+// neither begins nor commits, and its writes go when the outer one rolls back.
+func TestWithTx_RepositoryJoinsOuterTransaction(t *testing.T) {
+	manager, mock, closeDB := newMockDB(t)
+	defer closeDB()
+	customers := NewCustomerRepository(manager.db, time.Second)
+	debts := NewDebtRepository(manager.db, time.Second)
+	customerID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM customers WHERE id = \$1 FOR UPDATE`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(customerID))
+	mock.ExpectQuery(`SELECT customer_id FROM sales`).
+		WillReturnRows(sqlmock.NewRows([]string{"customer_id"}).AddRow(customerID))
+	mock.ExpectQuery(`SELECT outstanding FROM sale_debts`).
+		WillReturnRows(sqlmock.NewRows([]string{"outstanding"}).AddRow(0))
+	mock.ExpectRollback()
+
+	err := manager.WithTx(context.Background(), func(ctx context.Context) error {
+		if err := customers.Lock(ctx, customerID); err != nil {
+			return err
+		}
+		_, err := debts.PaySale(ctx, uuid.New(), &models.CreateDebtPaymentRequest{Amount: models.NewMoney(1000)})
+		return err
+	})
+	if !errors.Is(err, ErrNoOutstandingDebt) {
+		t.Errorf("Expected no outstanding debt error, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWithTx_NestedCallsShareTransaction(t *testing.T) {
+	manager, mock, closeDB := newMockDB(t)
+	defer closeDB()
+	failed := errors.New("failed after the inner call")
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE customers`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	err := manager.WithTx(context.Background(), func(ctx context.Context) error {
+		err := manager.WithTx(ctx, func(ctx context.Context) error {
+			_, err := conn(ctx, manager.db).ExecContext(ctx, `UPDATE customers SET notes = '' WHERE id = $1`, uuid.New())
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Errorf("Expected the outer error, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
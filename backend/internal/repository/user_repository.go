@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"pencatatan/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type UserRepository interface {
+	Create(ctx context.Context, user *models.CreateUserRequest, passwordHash string) (*models.User, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	GetByUsername(ctx context.Context, username string) (*models.User, error)
+}
+
+type userRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+func NewUserRepository(db *sql.DB, timeout time.Duration) UserRepository {
+	return &userRepository{
+		db:      db,
+		timeout: timeout,
+	}
+}
+
+const userColumns = `id, username, name, role, password_hash, is_active, created_at, updated_at`
+
+func scanUser(row rowScanner) (*models.User, error) {
+	var user models.User
+	err := row.Scan(
+		&user.ID,
+		&user.Username,
+		&user.Name,
+		&user.Role,
+		&user.PasswordHash,
+		&user.IsActive,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) Create(ctx context.Context, userReq *models.CreateUserRequest, passwordHash string) (*models.User, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `INSERT INTO users (username, name, role, password_hash)
+				VALUES ($1, $2, $3, $4)
+				RETURNING ` + userColumns
+
+	user, err := scanUser(conn(ctx, r.db).QueryRowContext(ctx, query, userReq.Username, userReq.Name, userReq.Role, passwordHash))
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return user, nil
+}
+
+func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
+
+	user, err := scanUser(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// GetByUsername looks a user up ignoring case, matching the unique index.
+func (r *userRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `SELECT ` + userColumns + ` FROM users WHERE lower(username) = lower($1)`
+
+	user, err := scanUser(conn(ctx, r.db).QueryRowContext(ctx, query, username))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, userID uuid.UUID, tokenHash string, expiresAt time.Time) (*models.RefreshToken, error)
+	Consume(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	Revoke(ctx context.Context, tokenHash string) error
+}
+
+type refreshTokenRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+func NewRefreshTokenRepository(db *sql.DB, timeout time.Duration) RefreshTokenRepository {
+	return &refreshTokenRepository{
+		db:      db,
+		timeout: timeout,
+	}
+}
+
+const refreshTokenColumns = `id, user_id, token_hash, expires_at, revoked_at, created_at`
+
+func scanRefreshToken(row rowScanner) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := row.Scan(
+		&token.ID,
+		&token.UserID,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&token.RevokedAt,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *refreshTokenRepository) Create(ctx context.Context, userID uuid.UUID, tokenHash string, expiresAt time.Time) (*models.RefreshToken, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `INSERT INTO refresh_tokens (user_id, token_hash, expires_at)
+				VALUES ($1, $2, $3)
+				RETURNING ` + refreshTokenColumns
+
+	return scanRefreshToken(conn(ctx, r.db).QueryRowContext(ctx, query, userID, tokenHash, expiresAt))
+}
+
+// Consume revokes a live token and returns it. The update is a single
+// statement, so when the same token is sent twice at once only one request
+// gets it back; the other sees (nil, nil) like an unknown or expired token.
+func (r *refreshTokenRepository) Consume(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `UPDATE refresh_tokens
+				SET revoked_at = NOW()
+				WHERE token_hash = $1 AND revoked_at IS NULL AND expires_at > NOW()
+				RETURNING ` + refreshTokenColumns
+
+	token, err := scanRefreshToken(conn(ctx, r.db).QueryRowContext(ctx, query, tokenHash))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// Revoke marks a token as used. Revoking an unknown or already revoked token
+// is not an error, so logging out twice succeeds.
+func (r *refreshTokenRepository) Revoke(ctx context.Context, tokenHash string) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `UPDATE refresh_tokens SET revoked_at = NOW() WHERE token_hash = $1 AND revoked_at IS NULL`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, tokenHash)
+	return err
+}
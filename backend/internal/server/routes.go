@@ -3,30 +3,97 @@ package server
 import (
 	"net/http"
 	"pencatatan/internal/app"
+	"pencatatan/internal/config"
+	"pencatatan/internal/handler"
+	"pencatatan/internal/models"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
-func Register(r *gin.Engine, c *app.Container) http.Handler {
+func Register(r *gin.Engine, cfg *config.Config, c *app.Container) http.Handler {
+	r.Use(handler.RequestID, c.MetricsHandler.Observe, handler.RequestLogger, handler.Recovery)
+
 	r.Use(cors.New(cors.Config{
-		AllowAllOrigins:  true,
+		AllowOrigins:     cfg.CORSAllowedOrigins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
-		AllowHeaders:     []string{"Accept", "Authorization", "Content-Type"},
+		AllowHeaders:     []string{"Accept", "Authorization", "Content-Type", "If-Match", "Idempotency-Key", "X-Request-ID"},
+		ExposeHeaders:    []string{"ETag", "Idempotent-Replayed", "X-Request-ID"},
 		AllowCredentials: true,
 	}))
 
+	r.Use(handler.ErrorHandler)
+
 	r.GET("/health", c.HealthHandler.Check)
+	r.GET("/metrics", c.MetricsHandler.Serve)
 
 	api := r.Group("/api")
 
+	auth := api.Group("/auth")
+	{
+		auth.POST("/login", c.AuthHandler.Login)
+		auth.POST("/refresh", c.AuthHandler.Refresh)
+		auth.POST("/logout", c.AuthHandler.Logout)
+	}
+
+	// Everything registered on api below this point needs a logged in user.
+	api = api.Group("", c.AuthHandler.RequireAuth)
+
+	api.GET("/auth/me", c.AuthHandler.Me)
+
+	viewSales := c.AuthHandler.RequirePermission(models.PermissionViewSales)
+	recordSales := c.AuthHandler.RequirePermission(models.PermissionRecordSales)
+	manageSales := c.AuthHandler.RequirePermission(models.PermissionManageSales)
+	manageCatalog := c.AuthHandler.RequirePermission(models.PermissionManageCatalog)
+
+	// Editing and deleting only need recordSales here; SaleService limits
+	// users without manageSales to sales dated today.
 	sales := api.Group("/sales")
 	{
-		sales.POST("", c.SaleHandler.CreateSale)
-		sales.GET("/:id", c.SaleHandler.GetSaleByID)
-		sales.GET("", c.SaleHandler.GetAllSales)
-		sales.PUT("/:id", c.SaleHandler.UpdateSale)
-		sales.DELETE("/:id", c.SaleHandler.DeleteSale)
+		sales.POST("", recordSales, c.IdempotencyHandler.Idempotent, c.SaleHandler.CreateSale)
+		sales.GET("/search", viewSales, c.SaleHandler.SearchSales)
+		sales.GET("/export", viewSales, c.SaleHandler.ExportSales)
+		sales.POST("/import", manageSales, c.SaleHandler.ImportSales)
+		sales.GET("/trash", manageSales, c.SaleHandler.GetTrash)
+		sales.GET("/:id", viewSales, c.SaleHandler.GetSaleByID)
+		sales.GET("", viewSales, c.SaleHandler.GetAllSales)
+		sales.PATCH("/:id", recordSales, c.SaleHandler.UpdateSale)
+		// PUT is kept for older clients; it applies only the fields sent, like PATCH.
+		sales.PUT("/:id", recordSales, c.SaleHandler.UpdateSale)
+		sales.DELETE("/:id", recordSales, c.SaleHandler.DeleteSale)
+		sales.POST("/:id/restore", manageSales, c.SaleHandler.RestoreSale)
+		sales.GET("/:id/history", viewSales, c.SaleHandler.GetSaleHistory)
+		sales.GET("/:id/debt", viewSales, c.DebtHandler.GetSaleDebt)
+		sales.POST("/:id/payments", recordSales, c.DebtHandler.PaySale)
+	}
+
+	products := api.Group("/products")
+	{
+		products.POST("", manageCatalog, c.ProductHandler.CreateProduct)
+		products.GET("/price-list", viewSales, c.ProductHandler.GetPriceList)
+		products.GET("/:id", viewSales, c.ProductHandler.GetProductByID)
+		products.GET("", viewSales, c.ProductHandler.GetAllProducts)
+		products.PUT("/:id", manageCatalog, c.ProductHandler.UpdateProduct)
+		products.DELETE("/:id", manageCatalog, c.ProductHandler.DeleteProduct)
+	}
+
+	customers := api.Group("/customers")
+	{
+		customers.POST("", manageCatalog, c.CustomerHandler.CreateCustomer)
+		customers.GET("/:id", viewSales, c.CustomerHandler.GetCustomerByID)
+		customers.GET("", viewSales, c.CustomerHandler.GetAllCustomers)
+		customers.PUT("/:id", manageCatalog, c.CustomerHandler.UpdateCustomer)
+		customers.DELETE("/:id", manageCatalog, c.CustomerHandler.DeleteCustomer)
+		customers.GET("/:id/balance", viewSales, c.DebtHandler.GetCustomerBalance)
+		customers.POST("/:id/payments", recordSales, c.DebtHandler.PayCustomer)
+	}
+
+	api.GET("/debts", viewSales, c.DebtHandler.GetOpenDebts)
+
+	reports := api.Group("/reports", c.AuthHandler.RequirePermission(models.PermissionViewReports))
+	{
+		reports.GET("/debt-aging", c.ReportHandler.DebtAging)
+		reports.GET("/summary", c.ReportHandler.SalesSummary)
 	}
 
 	return r
@@ -11,8 +11,8 @@ import (
 )
 
 func NewServer(cfg *config.Config, c *app.Container) *http.Server {
-	r := gin.Default()
-	Register(r, c)
+	r := gin.New()
+	Register(r, cfg, c)
 
 	return &http.Server{
 		Addr:         fmt.Sprintf(":%s", cfg.ServerPort),
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"pencatatan/internal/models"
+	"pencatatan/internal/repository"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrInvalidCredentials = newError(KindUnauthorized, "invalid_credentials", "invalid username or password")
+	ErrInvalidToken       = newError(KindUnauthorized, "invalid_token", "invalid or expired token")
+	ErrUserExists         = newError(KindConflict, "user_exists", "username is already taken")
+	ErrForbidden          = newError(KindForbidden, "forbidden", "you are not allowed to do this")
+)
+
+// dummyPasswordHash is compared against when a username does not exist, so a
+// failed login takes as long for unknown users as for wrong passwords.
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("pencatatan"), bcrypt.DefaultCost)
+	return hash
+})
+
+type AuthService interface {
+	Login(ctx context.Context, req *models.LoginRequest) (*models.AuthTokens, error)
+	Refresh(ctx context.Context, refreshToken string) (*models.AuthTokens, error)
+	Logout(ctx context.Context, refreshToken string) error
+	Authenticate(ctx context.Context, accessToken string) (*models.User, error)
+	CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, error)
+}
+
+type authService struct {
+	userRepo   repository.UserRepository
+	tokenRepo  repository.RefreshTokenRepository
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+func NewAuthService(userRepo repository.UserRepository, tokenRepo repository.RefreshTokenRepository, secret string, accessTTL, refreshTTL time.Duration) AuthService {
+	return &authService{
+		userRepo:   userRepo,
+		tokenRepo:  tokenRepo,
+		secret:     []byte(secret),
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+	}
+}
+
+func (s *authService) Login(ctx context.Context, req *models.LoginRequest) (*models.AuthTokens, error) {
+	user, err := s.userRepo.GetByUsername(ctx, strings.TrimSpace(req.Username))
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		_ = bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(req.Password))
+		return nil, ErrInvalidCredentials
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	if !user.IsActive {
+		return nil, ErrInvalidCredentials
+	}
+
+	return s.issueTokens(ctx, user)
+}
+
+// Refresh exchanges a refresh token for a new token pair. The old refresh
+// token is revoked, so each one can be used only once.
+func (s *authService) Refresh(ctx context.Context, refreshToken string) (*models.AuthTokens, error) {
+	token, err := s.tokenRepo.Consume(ctx, hashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+
+	if token == nil {
+		return nil, ErrInvalidToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, token.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil || !user.IsActive {
+		return nil, ErrInvalidToken
+	}
+
+	return s.issueTokens(ctx, user)
+}
+
+// Logout revokes the refresh token. The access token stays valid until it
+// expires, which is why its lifetime is kept short.
+func (s *authService) Logout(ctx context.Context, refreshToken string) error {
+	return s.tokenRepo.Revoke(ctx, hashToken(refreshToken))
+}
+
+// Authenticate checks an access token and returns its user. Users that were
+// deactivated or removed are rejected even while their token is unexpired.
+func (s *authService) Authenticate(ctx context.Context, accessToken string) (*models.User, error) {
+	var claims jwt.RegisteredClaims
+	_, err := jwt.ParseWithClaims(accessToken, &claims, func(*jwt.Token) (interface{}, error) {
+		return s.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	uid, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil || !user.IsActive {
+		return nil, ErrInvalidToken
+	}
+
+	return user, nil
+}
+
+func (s *authService) CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
+	req.Username = strings.TrimSpace(req.Username)
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Role == "" {
+		req.Role = models.RoleCashier
+	}
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		return nil, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.Create(ctx, req, string(hash))
+	if errors.Is(err, repository.ErrDuplicate) {
+		return nil, ErrUserExists
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *authService) issueTokens(ctx context.Context, user *models.User) (*models.AuthTokens, error) {
+	now := time.Now()
+	accessToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Subject:   user.ID.String(),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(s.accessTTL)),
+	}).SignedString(s.secret)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	refreshToken := base64.RawURLEncoding.EncodeToString(raw)
+
+	if _, err := s.tokenRepo.Create(ctx, user.ID, hashToken(refreshToken), now.Add(s.refreshTTL)); err != nil {
+		return nil, err
+	}
+
+	return &models.AuthTokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.accessTTL / time.Second),
+		User:         user,
+	}, nil
+}
+
+// hashToken returns the form a refresh token is stored in. Refresh tokens are
+// long random strings, so a fast hash is enough.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"pencatatan/internal/models"
+	"pencatatan/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testJWTSecret = "test-secret-that-is-at-least-32-bytes"
+
+// newTestUser returns an active user whose password is "rahasia123".
+func newTestUser(t *testing.T) *models.User {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte("rahasia123"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &models.User{ID: uuid.New(), Username: "kasir", PasswordHash: string(hash), IsActive: true}
+}
+
+func newTestAuthService(user *models.User, tokenRepo *repository.MockRefreshTokenRepository, accessTTL time.Duration) AuthService {
+	userRepo := &repository.MockUserRepository{
+		GetByUsernameFunc: func(ctx context.Context, username string) (*models.User, error) {
+			if username == user.Username {
+				return user, nil
+			}
+			return nil, nil
+		},
+		GetByIDFunc: func(ctx context.Context, id uuid.UUID) (*models.User, error) {
+			if id == user.ID {
+				return user, nil
+			}
+			return nil, nil
+		},
+	}
+	return NewAuthService(userRepo, tokenRepo, testJWTSecret, accessTTL, time.Hour)
+}
+
+func TestLogin_Success(t *testing.T) {
+	user := newTestUser(t)
+	var storedHash string
+	tokenRepo := &repository.MockRefreshTokenRepository{
+		CreateFunc: func(ctx context.Context, userID uuid.UUID, tokenHash string, expiresAt time.Time) (*models.RefreshToken, error) {
+			storedHash = tokenHash
+			return &models.RefreshToken{UserID: userID, TokenHash: tokenHash, ExpiresAt: expiresAt}, nil
+		},
+	}
+	service := newTestAuthService(user, tokenRepo, time.Minute)
+
+	tokens, err := service.Login(context.Background(), &models.LoginRequest{Username: "kasir", Password: "rahasia123"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if storedHash != hashToken(tokens.RefreshToken) || storedHash == tokens.RefreshToken {
+		t.Error("Expected only the hash of the refresh token to be stored")
+	}
+
+	authenticated, err := service.Authenticate(context.Background(), tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("Expected access token to authenticate, got %v", err)
+	}
+	if authenticated.ID != user.ID {
+		t.Errorf("Expected user %s, got %s", user.ID, authenticated.ID)
+	}
+}
+
+func TestLogin_InvalidCredentials(t *testing.T) {
+	user := newTestUser(t)
+	service := newTestAuthService(user, &repository.MockRefreshTokenRepository{}, time.Minute)
+
+	cases := []*models.LoginRequest{
+		{Username: "kasir", Password: "salah"},
+		{Username: "tidak-ada", Password: "rahasia123"},
+	}
+	for _, req := range cases {
+		if _, err := service.Login(context.Background(), req); !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("Login(%q): expected invalid credentials, got %v", req.Username, err)
+		}
+	}
+
+	user.IsActive = false
+	if _, err := service.Login(context.Background(), &models.LoginRequest{Username: "kasir", Password: "rahasia123"}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected inactive user to be rejected, got %v", err)
+	}
+}
+
+func TestAuthenticate_RejectsBadTokens(t *testing.T) {
+	user := newTestUser(t)
+	expired := newTestAuthService(user, &repository.MockRefreshTokenRepository{}, -time.Minute)
+
+	tokens, err := expired.Login(context.Background(), &models.LoginRequest{Username: "kasir", Password: "rahasia123"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, err := expired.Authenticate(context.Background(), tokens.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected expired token to be rejected, got %v", err)
+	}
+
+	other := NewAuthService(&repository.MockUserRepository{}, &repository.MockRefreshTokenRepository{},
+		"another-secret-that-is-32-bytes-long", time.Minute, time.Hour)
+	valid := newTestAuthService(user, &repository.MockRefreshTokenRepository{}, time.Minute)
+	tokens, _ = valid.Login(context.Background(), &models.LoginRequest{Username: "kasir", Password: "rahasia123"})
+
+	if _, err := other.Authenticate(context.Background(), tokens.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected token signed with another secret to be rejected, got %v", err)
+	}
+
+	if _, err := valid.Authenticate(context.Background(), "not-a-jwt"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected garbage token to be rejected, got %v", err)
+	}
+}
+
+func TestRefresh_RotatesToken(t *testing.T) {
+	user := newTestUser(t)
+	consumed := map[string]bool{}
+	tokenRepo := &repository.MockRefreshTokenRepository{
+		ConsumeFunc: func(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+			if consumed[tokenHash] {
+				return nil, nil
+			}
+			consumed[tokenHash] = true
+			return &models.RefreshToken{UserID: user.ID, TokenHash: tokenHash}, nil
+		},
+	}
+	service := newTestAuthService(user, tokenRepo, time.Minute)
+
+	tokens, err := service.Refresh(context.Background(), "old-token")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if tokens.RefreshToken == "old-token" {
+		t.Error("Expected a new refresh token")
+	}
+
+	if _, err := service.Refresh(context.Background(), "old-token"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected reused refresh token to be rejected, got %v", err)
+	}
+}
+
+func TestCreateUser_Duplicate(t *testing.T) {
+	userRepo := &repository.MockUserRepository{
+		CreateFunc: func(ctx context.Context, user *models.CreateUserRequest, passwordHash string) (*models.User, error) {
+			return nil, repository.ErrDuplicate
+		},
+	}
+	service := NewAuthService(userRepo, &repository.MockRefreshTokenRepository{}, testJWTSecret, time.Minute, time.Hour)
+
+	_, err := service.CreateUser(context.Background(), &models.CreateUserRequest{Username: "kasir", Password: "rahasia123"})
+
+	if !errors.Is(err, ErrUserExists) {
+		t.Errorf("Expected user exists error, got %v", err)
+	}
+}
+
+func TestCreateUser_HashesPassword(t *testing.T) {
+	userRepo := &repository.MockUserRepository{
+		CreateFunc: func(ctx context.Context, user *models.CreateUserRequest, passwordHash string) (*models.User, error) {
+			if bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(user.Password)) != nil {
+				t.Error("Expected a bcrypt hash of the password")
+			}
+			return &models.User{ID: uuid.New(), Username: user.Username}, nil
+		},
+	}
+	service := NewAuthService(userRepo, &repository.MockRefreshTokenRepository{}, testJWTSecret, time.Minute, time.Hour)
+
+	if _, err := service.CreateUser(context.Background(), &models.CreateUserRequest{Username: " kasir ", Password: "rahasia123"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, err := service.CreateUser(context.Background(), &models.CreateUserRequest{Username: "kasir", Password: "pendek"}); err == nil {
+		t.Error("Expected a short password to be rejected")
+	}
+}
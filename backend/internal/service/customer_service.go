@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"pencatatan/internal/models"
+	"pencatatan/internal/repository"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrCustomerNotFound = newError(KindNotFound, "customer_not_found", "customer not found")
+	ErrCustomerInvalid  = newError(KindValidation, "customer_invalid", "customer name must not be blank")
+)
+
+type CustomerService interface {
+	CreateCustomer(ctx context.Context, req *models.CreateCustomerRequest) (*models.Customer, error)
+	GetCustomerByID(ctx context.Context, id string) (*models.Customer, error)
+	GetAllCustomers(ctx context.Context, q string) ([]*models.Customer, error)
+	UpdateCustomer(ctx context.Context, id string, req *models.UpdateCustomerRequest) (*models.Customer, error)
+	DeleteCustomer(ctx context.Context, id string) error
+}
+
+type customerService struct {
+	repo repository.CustomerRepository
+}
+
+func NewCustomerService(repo repository.CustomerRepository) CustomerService {
+	return &customerService{
+		repo: repo,
+	}
+}
+
+func (s *customerService) CreateCustomer(ctx context.Context, req *models.CreateCustomerRequest) (*models.Customer, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return nil, ErrCustomerInvalid
+	}
+
+	return s.repo.Create(ctx, req)
+}
+
+func (s *customerService) GetCustomerByID(ctx context.Context, id string) (*models.Customer, error) {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+
+	customer, err := s.repo.GetByID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	if customer == nil {
+		return nil, ErrCustomerNotFound
+	}
+
+	return customer, nil
+}
+
+func (s *customerService) GetAllCustomers(ctx context.Context, q string) ([]*models.Customer, error) {
+	customers, err := s.repo.GetAll(ctx, strings.TrimSpace(q))
+	if err != nil {
+		return nil, err
+	}
+
+	if customers == nil {
+		customers = []*models.Customer{}
+	}
+
+	return customers, nil
+}
+
+func (s *customerService) UpdateCustomer(ctx context.Context, id string, req *models.UpdateCustomerRequest) (*models.Customer, error) {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+
+	if req.Name != nil {
+		*req.Name = strings.TrimSpace(*req.Name)
+		if *req.Name == "" {
+			return nil, ErrCustomerInvalid
+		}
+	}
+
+	customer, err := s.repo.Update(ctx, uid, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if customer == nil {
+		return nil, ErrCustomerNotFound
+	}
+
+	return customer, nil
+}
+
+func (s *customerService) DeleteCustomer(ctx context.Context, id string) error {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return ErrInvalidID
+	}
+
+	err = s.repo.Delete(ctx, uid)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrCustomerNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"pencatatan/internal/models"
+	"pencatatan/internal/repository"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCreateCustomer_Success(t *testing.T) {
+	mockRepo := &repository.MockCustomerRepository{
+		CreateFunc: func(ctx context.Context, req *models.CreateCustomerRequest) (*models.Customer, error) {
+			return &models.Customer{ID: uuid.New(), Name: req.Name, CreditLimit: req.CreditLimit}, nil
+		},
+	}
+
+	service := NewCustomerService(mockRepo)
+
+	customer, err := service.CreateCustomer(context.Background(), &models.CreateCustomerRequest{
+		Name:        "  Pak Budi ",
+		CreditLimit: models.NewMoney(500000),
+	})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if customer.Name != "Pak Budi" {
+		t.Errorf("Expected trimmed name, got %q", customer.Name)
+	}
+}
+
+func TestCreateCustomer_BlankName(t *testing.T) {
+	service := NewCustomerService(&repository.MockCustomerRepository{})
+
+	_, err := service.CreateCustomer(context.Background(), &models.CreateCustomerRequest{Name: "   "})
+
+	if !errors.Is(err, ErrCustomerInvalid) {
+		t.Errorf("Expected invalid customer error, got %v", err)
+	}
+}
+
+func TestGetCustomerByID_NotFound(t *testing.T) {
+	service := NewCustomerService(&repository.MockCustomerRepository{})
+
+	_, err := service.GetCustomerByID(context.Background(), uuid.New().String())
+
+	if !errors.Is(err, ErrCustomerNotFound) {
+		t.Errorf("Expected customer not found error, got %v", err)
+	}
+}
+
+func TestDeleteCustomer_InvalidUUID(t *testing.T) {
+	service := NewCustomerService(&repository.MockCustomerRepository{})
+
+	err := service.DeleteCustomer(context.Background(), "invalid-uuid")
+
+	if err == nil || err.Error() != "invalid UUID format" {
+		t.Errorf("Expected invalid UUID error, got %v", err)
+	}
+}
+
+func TestDeleteCustomer_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		repoErr error
+		kind    ErrorKind
+	}{
+		{"not found", sql.ErrNoRows, KindNotFound},
+		{"database error", errors.New("connection reset"), KindInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewCustomerService(&repository.MockCustomerRepository{
+				DeleteFunc: func(ctx context.Context, id uuid.UUID) error {
+					return tt.repoErr
+				},
+			})
+
+			err := service.DeleteCustomer(context.Background(), uuid.New().String())
+
+			if err == nil || KindOf(err) != tt.kind {
+				t.Errorf("Expected kind %d, got %v", tt.kind, err)
+			}
+		})
+	}
+}
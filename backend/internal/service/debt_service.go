@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"pencatatan/internal/models"
+	"pencatatan/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNoOutstandingDebt   = newError(KindUnprocessable, "no_outstanding_debt", "there is no outstanding debt to pay")
+	ErrPaymentExceedsDebt  = newError(KindUnprocessable, "payment_exceeds_debt", "payment is larger than the outstanding debt")
+	ErrCreditLimitExceeded = newError(KindValidation, "credit_limit_exceeded", "sale would exceed the customer's credit limit")
+)
+
+type DebtService interface {
+	PaySale(ctx context.Context, saleID string, req *models.CreateDebtPaymentRequest) (*models.SalePaymentResult, error)
+	PayCustomer(ctx context.Context, customerID string, req *models.CreateDebtPaymentRequest) (*models.CustomerPaymentResult, error)
+	GetSaleDebt(ctx context.Context, saleID string) (*models.SaleDebt, error)
+	GetCustomerBalance(ctx context.Context, customerID string) (*models.CustomerBalance, error)
+	GetOpenDebts(ctx context.Context, customerID string) ([]*models.SaleDebt, error)
+}
+
+type debtService struct {
+	repo         repository.DebtRepository
+	customerRepo repository.CustomerRepository
+}
+
+func NewDebtService(repo repository.DebtRepository, customerRepo repository.CustomerRepository) DebtService {
+	return &debtService{
+		repo:         repo,
+		customerRepo: customerRepo,
+	}
+}
+
+func debtError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrNoOutstandingDebt):
+		return ErrNoOutstandingDebt
+	case errors.Is(err, repository.ErrExceedsOutstanding):
+		return ErrPaymentExceedsDebt
+	}
+	return err
+}
+
+func (s *debtService) PaySale(ctx context.Context, saleID string, req *models.CreateDebtPaymentRequest) (*models.SalePaymentResult, error) {
+	uid, err := uuid.Parse(saleID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+
+	payment, err := s.repo.PaySale(ctx, uid, req)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSaleNotFound
+	}
+	if err != nil {
+		return nil, debtError(err)
+	}
+
+	debt, err := s.repo.GetSaleDebt(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.SalePaymentResult{Payment: payment, Debt: debt}, nil
+}
+
+func (s *debtService) PayCustomer(ctx context.Context, customerID string, req *models.CreateDebtPaymentRequest) (*models.CustomerPaymentResult, error) {
+	uid, err := s.existingCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	payments, err := s.repo.PayCustomer(ctx, uid, req)
+	if err != nil {
+		return nil, debtError(err)
+	}
+
+	balance, err := s.repo.GetCustomerBalance(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.CustomerPaymentResult{Payments: payments, Balance: balance}, nil
+}
+
+func (s *debtService) GetSaleDebt(ctx context.Context, saleID string) (*models.SaleDebt, error) {
+	uid, err := uuid.Parse(saleID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+
+	debt, err := s.repo.GetSaleDebt(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	if debt == nil {
+		return nil, ErrSaleNotFound
+	}
+
+	debt.Payments, err = s.repo.GetPayments(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	return debt, nil
+}
+
+func (s *debtService) GetCustomerBalance(ctx context.Context, customerID string) (*models.CustomerBalance, error) {
+	uid, err := s.existingCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetCustomerBalance(ctx, uid)
+}
+
+func (s *debtService) GetOpenDebts(ctx context.Context, customerID string) ([]*models.SaleDebt, error) {
+	var filter *uuid.UUID
+	if customerID != "" {
+		uid, err := uuid.Parse(customerID)
+		if err != nil {
+			return nil, ErrInvalidID
+		}
+		filter = &uid
+	}
+
+	debts, err := s.repo.GetOpenDebts(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if debts == nil {
+		debts = []*models.SaleDebt{}
+	}
+
+	return debts, nil
+}
+
+func (s *debtService) existingCustomer(ctx context.Context, id string) (uuid.UUID, error) {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, ErrInvalidID
+	}
+
+	customer, err := s.customerRepo.GetByID(ctx, uid)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if customer == nil {
+		return uuid.Nil, ErrCustomerNotFound
+	}
+
+	return uid, nil
+}
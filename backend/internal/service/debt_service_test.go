@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"pencatatan/internal/models"
+	"pencatatan/internal/repository"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestPaySale_Success(t *testing.T) {
+	saleID := uuid.New()
+	mockRepo := &repository.MockDebtRepository{
+		PaySaleFunc: func(ctx context.Context, id uuid.UUID, req *models.CreateDebtPaymentRequest) (*models.DebtPayment, error) {
+			return &models.DebtPayment{ID: uuid.New(), SaleID: id, Amount: req.Amount}, nil
+		},
+		GetSaleDebtFunc: func(ctx context.Context, id uuid.UUID) (*models.SaleDebt, error) {
+			return &models.SaleDebt{SaleID: id, DebtAmount: models.NewMoney(20000), PaidAmount: models.NewMoney(10000), Outstanding: models.NewMoney(10000)}, nil
+		},
+	}
+
+	service := NewDebtService(mockRepo, &repository.MockCustomerRepository{})
+
+	result, err := service.PaySale(context.Background(), saleID.String(), &models.CreateDebtPaymentRequest{Amount: models.NewMoney(10000)})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if result.Payment.SaleID != saleID {
+		t.Errorf("Expected payment for sale %s, got %s", saleID, result.Payment.SaleID)
+	}
+
+	if result.Debt.Outstanding != models.NewMoney(10000) {
+		t.Errorf("Expected outstanding 10000.00, got %s", result.Debt.Outstanding)
+	}
+}
+
+func TestPaySale_Errors(t *testing.T) {
+	tests := []struct {
+		repoErr error
+		want    error
+	}{
+		{repoErr: sql.ErrNoRows, want: ErrSaleNotFound},
+		{repoErr: repository.ErrNoOutstandingDebt, want: ErrNoOutstandingDebt},
+		{repoErr: repository.ErrExceedsOutstanding, want: ErrPaymentExceedsDebt},
+	}
+
+	for _, tt := range tests {
+		mockRepo := &repository.MockDebtRepository{
+			PaySaleFunc: func(ctx context.Context, id uuid.UUID, req *models.CreateDebtPaymentRequest) (*models.DebtPayment, error) {
+				return nil, tt.repoErr
+			},
+		}
+
+		service := NewDebtService(mockRepo, &repository.MockCustomerRepository{})
+
+		_, err := service.PaySale(context.Background(), uuid.New().String(), &models.CreateDebtPaymentRequest{Amount: 100})
+
+		if !errors.Is(err, tt.want) {
+			t.Errorf("Expected %v, got %v", tt.want, err)
+		}
+	}
+}
+
+func TestPayCustomer_UnknownCustomer(t *testing.T) {
+	service := NewDebtService(&repository.MockDebtRepository{}, &repository.MockCustomerRepository{})
+
+	_, err := service.PayCustomer(context.Background(), uuid.New().String(), &models.CreateDebtPaymentRequest{Amount: 100})
+
+	if !errors.Is(err, ErrCustomerNotFound) {
+		t.Errorf("Expected customer not found error, got %v", err)
+	}
+}
+
+func TestGetSaleDebt_IncludesPayments(t *testing.T) {
+	mockRepo := &repository.MockDebtRepository{
+		GetSaleDebtFunc: func(ctx context.Context, id uuid.UUID) (*models.SaleDebt, error) {
+			return &models.SaleDebt{SaleID: id}, nil
+		},
+		GetPaymentsFunc: func(ctx context.Context, id uuid.UUID) ([]*models.DebtPayment, error) {
+			return []*models.DebtPayment{{ID: uuid.New(), SaleID: id}, {ID: uuid.New(), SaleID: id}}, nil
+		},
+	}
+
+	service := NewDebtService(mockRepo, &repository.MockCustomerRepository{})
+
+	debt, err := service.GetSaleDebt(context.Background(), uuid.New().String())
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(debt.Payments) != 2 {
+		t.Errorf("Expected 2 payments, got %d", len(debt.Payments))
+	}
+}
+
+func TestGetOpenDebts_InvalidCustomerID(t *testing.T) {
+	service := NewDebtService(&repository.MockDebtRepository{}, &repository.MockCustomerRepository{})
+
+	_, err := service.GetOpenDebts(context.Background(), "invalid-uuid")
+
+	if !errors.Is(err, ErrInvalidID) {
+		t.Errorf("Expected invalid id error, got %v", err)
+	}
+}
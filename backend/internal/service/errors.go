@@ -0,0 +1,78 @@
+package service
+
+import (
+	"errors"
+)
+
+// ErrorKind classifies service errors, so callers can react to a whole class
+// of errors, such as answering every not found error with 404.
+type ErrorKind int
+
+const (
+	// KindInternal is any error that is not an *Error, such as a lost
+	// database connection.
+	KindInternal ErrorKind = iota
+	// KindValidation means the request itself is invalid.
+	KindValidation
+	// KindNotFound means the record the request is about does not exist.
+	KindNotFound
+	// KindConflict means the request clashes with existing data.
+	KindConflict
+	// KindUnprocessable means the request is valid but breaks a business rule
+	// in the current state of the data.
+	KindUnprocessable
+	KindUnauthorized
+	KindForbidden
+	// KindPreconditionRequired and KindPreconditionFailed are about the
+	// version a client must send when changing a record.
+	KindPreconditionRequired
+	KindPreconditionFailed
+)
+
+// Error is a domain error with a machine readable Code that clients can rely
+// on, while Message is meant for people. Err is the wrapped cause, if any.
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+	Err     error
+}
+
+func newError(kind ErrorKind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// KindOf returns the kind of the first *Error in err's chain, or KindInternal
+// when there is none.
+func KindOf(err error) ErrorKind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return KindInternal
+}
+
+// invalid wraps an error caused by bad input from outside this package, such
+// as a malformed cursor, as a validation error.
+func invalid(code string, err error) error {
+	return &Error{Kind: KindValidation, Code: code, Message: err.Error(), Err: err}
+}
+
+// invalidReference turns a not found error for a record the request refers
+// to, like a product on a new sale, into a validation error: the request is
+// wrong, not the URL. errors.Is still matches the original error.
+func invalidReference(err error) error {
+	var e *Error
+	if errors.As(err, &e) && e.Kind == KindNotFound {
+		return &Error{Kind: KindValidation, Code: e.Code, Message: e.Message, Err: err}
+	}
+	return err
+}
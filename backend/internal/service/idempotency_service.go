@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"pencatatan/internal/models"
+	"pencatatan/internal/repository"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrIdempotencyKeyReused     = newError(KindUnprocessable, "idempotency_key_reused", "idempotency key was already used for a different request")
+	ErrIdempotencyKeyInProgress = newError(KindConflict, "idempotency_key_in_progress", "a request with this idempotency key is still being processed, retry later")
+)
+
+type IdempotencyService interface {
+	Begin(ctx context.Context, userID uuid.UUID, key, requestHash string) (*models.IdempotencyKey, error)
+	KeepAlive(ctx context.Context, claim *models.IdempotencyKey) (stop func())
+	Complete(ctx context.Context, claim *models.IdempotencyKey, statusCode int, response []byte) error
+	Release(ctx context.Context, claim *models.IdempotencyKey) error
+	PurgeExpired(ctx context.Context) (int64, error)
+}
+
+type idempotencyService struct {
+	repo repository.IdempotencyRepository
+	ttl  time.Duration
+	// lease is how long a claimed key is held for a request that has not
+	// finished unless KeepAlive renews it. A request that crashed leaves its
+	// key to be taken over after the lease instead of blocking retries for the
+	// whole TTL.
+	lease time.Duration
+
+	// wait is how long Begin waits for a concurrent request with the same key
+	// to finish, checking every poll.
+	wait time.Duration
+	poll time.Duration
+}
+
+// NewIdempotencyService creates an IdempotencyService. Responses are kept for
+// ttl; a key whose request is still running is held for lease.
+func NewIdempotencyService(repo repository.IdempotencyRepository, ttl, lease time.Duration) IdempotencyService {
+	return &idempotencyService{
+		repo:  repo,
+		ttl:   ttl,
+		lease: lease,
+		wait:  5 * time.Second,
+		poll:  100 * time.Millisecond,
+	}
+}
+
+// Begin claims the key for a request. It returns either the stored response
+// of an earlier request with the same key, to send back instead, or a new
+// claim: the caller then runs the request and Completes or Releases the
+// claim. A duplicate sent while the first request is still running waits for
+// it.
+func (s *idempotencyService) Begin(ctx context.Context, userID uuid.UUID, key, requestHash string) (*models.IdempotencyKey, error) {
+	deadline := time.Now().Add(s.wait)
+
+	for {
+		token := uuid.New()
+		expiresAt := time.Now().Add(s.lease)
+		claimed, err := s.repo.Claim(ctx, userID, key, requestHash, token, expiresAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if claimed {
+			return &models.IdempotencyKey{
+				UserID:      userID,
+				Key:         key,
+				RequestHash: requestHash,
+				ClaimToken:  token,
+				ExpiresAt:   expiresAt,
+			}, nil
+		}
+
+		record, err := s.repo.Get(ctx, userID, key)
+		if err != nil {
+			return nil, err
+		}
+
+		// The key was released since the claim failed; try again.
+		if record == nil {
+			continue
+		}
+
+		if record.RequestHash != requestHash {
+			return nil, ErrIdempotencyKeyReused
+		}
+
+		if record.Completed() {
+			return record, nil
+		}
+
+		if time.Now().After(deadline) {
+			return nil, ErrIdempotencyKeyInProgress
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(s.poll):
+		}
+	}
+}
+
+// KeepAlive renews the claim's lease until stop is called, so a request that
+// runs longer than the lease keeps its key. The lease is renewed every third
+// of its length, which leaves room for a renewal to fail. stop may be called
+// more than once.
+func (s *idempotencyService) KeepAlive(ctx context.Context, claim *models.IdempotencyKey) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(s.lease / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			err := s.repo.Renew(ctx, claim.UserID, claim.Key, claim.ClaimToken, time.Now().Add(s.lease))
+			if errors.Is(err, repository.ErrClaimLost) {
+				slog.WarnContext(ctx, "idempotency key taken over by another request", "key", claim.Key)
+				return
+			}
+			if err != nil && ctx.Err() == nil {
+				slog.ErrorContext(ctx, "renew idempotency key", "error", err)
+			}
+		}
+	}()
+
+	return sync.OnceFunc(func() {
+		cancel()
+		wg.Wait()
+	})
+}
+
+// Complete stores the response of the claimed request for the TTL. It returns
+// repository.ErrClaimLost when the key was taken over by another request.
+func (s *idempotencyService) Complete(ctx context.Context, claim *models.IdempotencyKey, statusCode int, response []byte) error {
+	return s.repo.Complete(ctx, claim.UserID, claim.Key, claim.ClaimToken, statusCode, response, time.Now().Add(s.ttl))
+}
+
+func (s *idempotencyService) Release(ctx context.Context, claim *models.IdempotencyKey) error {
+	return s.repo.Release(ctx, claim.UserID, claim.Key, claim.ClaimToken)
+}
+
+// PurgeExpired deletes keys past their TTL.
+func (s *idempotencyService) PurgeExpired(ctx context.Context) (int64, error) {
+	return s.repo.DeleteExpired(ctx)
+}
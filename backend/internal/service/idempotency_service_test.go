@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"pencatatan/internal/models"
+	"pencatatan/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func newTestIdempotencyService(repo repository.IdempotencyRepository) *idempotencyService {
+	return &idempotencyService{repo: repo, ttl: time.Hour, lease: time.Minute, wait: 50 * time.Millisecond, poll: 10 * time.Millisecond}
+}
+
+func TestBegin_ClaimsNewKey(t *testing.T) {
+	var expiresAt time.Time
+	var claimToken uuid.UUID
+	mockRepo := &repository.MockIdempotencyRepository{
+		ClaimFunc: func(ctx context.Context, userID uuid.UUID, key, requestHash string, token uuid.UUID, expires time.Time) (bool, error) {
+			expiresAt = expires
+			claimToken = token
+			return true, nil
+		},
+	}
+	service := newTestIdempotencyService(mockRepo)
+
+	claim, err := service.Begin(context.Background(), uuid.New(), "retry-1", "hash")
+	if err != nil || claim == nil || claim.Completed() {
+		t.Fatalf("Expected the request to run, got %v, %v", claim, err)
+	}
+
+	if claim.ClaimToken == uuid.Nil || claim.ClaimToken != claimToken {
+		t.Errorf("Expected the claim to carry its token, got %v", claim.ClaimToken)
+	}
+
+	if until := time.Until(expiresAt); until < 59*time.Second || until > time.Minute {
+		t.Errorf("Expected the claim to last for the lease, got %v", expiresAt)
+	}
+}
+
+var claim = &models.IdempotencyKey{UserID: uuid.New(), Key: "retry-1", RequestHash: "hash", ClaimToken: uuid.New()}
+
+func TestComplete_KeepsResponseForTTL(t *testing.T) {
+	var expiresAt time.Time
+	mockRepo := &repository.MockIdempotencyRepository{
+		CompleteFunc: func(ctx context.Context, userID uuid.UUID, key string, token uuid.UUID, statusCode int, response []byte, expires time.Time) error {
+			if token != claim.ClaimToken {
+				return repository.ErrClaimLost
+			}
+			expiresAt = expires
+			return nil
+		},
+	}
+	service := newTestIdempotencyService(mockRepo)
+
+	if err := service.Complete(context.Background(), claim, 201, []byte(`{}`)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if time.Until(expiresAt) < 59*time.Minute {
+		t.Errorf("Expected the response to expire after the TTL, got %v", expiresAt)
+	}
+}
+
+func TestKeepAlive_RenewsLease(t *testing.T) {
+	renewed := make(chan uuid.UUID, 10)
+	mockRepo := &repository.MockIdempotencyRepository{
+		RenewFunc: func(ctx context.Context, userID uuid.UUID, key string, token uuid.UUID, expiresAt time.Time) error {
+			renewed <- token
+			return nil
+		},
+	}
+	service := newTestIdempotencyService(mockRepo)
+	service.lease = 30 * time.Millisecond
+
+	stop := service.KeepAlive(context.Background(), claim)
+	select {
+	case token := <-renewed:
+		if token != claim.ClaimToken {
+			t.Errorf("Expected the claim's token to be renewed, got %v", token)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the lease to be renewed")
+	}
+	stop()
+	stop()
+
+	for len(renewed) > 0 {
+		<-renewed
+	}
+	time.Sleep(3 * service.lease)
+	if len(renewed) != 0 {
+		t.Error("Expected no renewals after stop")
+	}
+}
+
+func TestBegin_ReplaysCompletedRequest(t *testing.T) {
+	status := 201
+	mockRepo := &repository.MockIdempotencyRepository{
+		ClaimFunc: func(ctx context.Context, userID uuid.UUID, key, requestHash string, token uuid.UUID, expiresAt time.Time) (bool, error) {
+			return false, nil
+		},
+		GetFunc: func(ctx context.Context, userID uuid.UUID, key string) (*models.IdempotencyKey, error) {
+			return &models.IdempotencyKey{RequestHash: "hash", StatusCode: &status, Response: []byte(`{"success":true}`)}, nil
+		},
+	}
+	service := newTestIdempotencyService(mockRepo)
+
+	stored, err := service.Begin(context.Background(), uuid.New(), "retry-1", "hash")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if stored == nil || *stored.StatusCode != 201 {
+		t.Errorf("Expected the stored response, got %+v", stored)
+	}
+}
+
+func TestBegin_KeyReusedForDifferentRequest(t *testing.T) {
+	mockRepo := &repository.MockIdempotencyRepository{
+		ClaimFunc: func(ctx context.Context, userID uuid.UUID, key, requestHash string, token uuid.UUID, expiresAt time.Time) (bool, error) {
+			return false, nil
+		},
+		GetFunc: func(ctx context.Context, userID uuid.UUID, key string) (*models.IdempotencyKey, error) {
+			return &models.IdempotencyKey{RequestHash: "other"}, nil
+		},
+	}
+	service := newTestIdempotencyService(mockRepo)
+
+	if _, err := service.Begin(context.Background(), uuid.New(), "retry-1", "hash"); !errors.Is(err, ErrIdempotencyKeyReused) {
+		t.Errorf("Expected key reused error, got %v", err)
+	}
+}
+
+func TestBegin_WaitsForConcurrentRequest(t *testing.T) {
+	status := 201
+	gets := 0
+	mockRepo := &repository.MockIdempotencyRepository{
+		ClaimFunc: func(ctx context.Context, userID uuid.UUID, key, requestHash string, token uuid.UUID, expiresAt time.Time) (bool, error) {
+			return false, nil
+		},
+		GetFunc: func(ctx context.Context, userID uuid.UUID, key string) (*models.IdempotencyKey, error) {
+			gets++
+			if gets < 3 {
+				return &models.IdempotencyKey{RequestHash: "hash"}, nil
+			}
+			return &models.IdempotencyKey{RequestHash: "hash", StatusCode: &status}, nil
+		},
+	}
+	service := newTestIdempotencyService(mockRepo)
+
+	stored, err := service.Begin(context.Background(), uuid.New(), "retry-1", "hash")
+	if err != nil || stored == nil {
+		t.Fatalf("Expected the response of the first request, got %v, %v", stored, err)
+	}
+}
+
+func TestBegin_InProgressTimesOut(t *testing.T) {
+	mockRepo := &repository.MockIdempotencyRepository{
+		ClaimFunc: func(ctx context.Context, userID uuid.UUID, key, requestHash string, token uuid.UUID, expiresAt time.Time) (bool, error) {
+			return false, nil
+		},
+		GetFunc: func(ctx context.Context, userID uuid.UUID, key string) (*models.IdempotencyKey, error) {
+			return &models.IdempotencyKey{RequestHash: "hash"}, nil
+		},
+	}
+	service := newTestIdempotencyService(mockRepo)
+
+	if _, err := service.Begin(context.Background(), uuid.New(), "retry-1", "hash"); !errors.Is(err, ErrIdempotencyKeyInProgress) {
+		t.Errorf("Expected in progress error, got %v", err)
+	}
+}
+
+func TestBegin_StopsWaitingWhenCancelled(t *testing.T) {
+	mockRepo := &repository.MockIdempotencyRepository{
+		ClaimFunc: func(ctx context.Context, userID uuid.UUID, key, requestHash string, token uuid.UUID, expiresAt time.Time) (bool, error) {
+			return false, nil
+		},
+		GetFunc: func(ctx context.Context, userID uuid.UUID, key string) (*models.IdempotencyKey, error) {
+			return &models.IdempotencyKey{RequestHash: "hash"}, nil
+		},
+	}
+	service := newTestIdempotencyService(mockRepo)
+	service.wait = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := service.Begin(ctx, uuid.New(), "retry-1", "hash"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context canceled, got %v", err)
+	}
+}
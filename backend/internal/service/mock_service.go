@@ -1,49 +1,370 @@
 package service
 
 import (
+	"context"
+	"io"
 	"pencatatan/internal/models"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 // MockSaleService is a mock implementation of SaleService for testing
 type MockSaleService struct {
-	CreateSaleFunc  func(req *models.CreateSalesRequest) (*models.Sale, error)
-	GetSaleByIDFunc func(id string) (*models.Sale, error)
-	GetAllSalesFunc func() ([]*models.Sale, error)
-	UpdateSalesFunc func(id string, req *models.UpdateSaleRequest) (*models.Sale, error)
-	DeleteSalesFunc func(id string) error
+	CreateSaleFunc     func(ctx context.Context, req *models.CreateSalesRequest) (*models.Sale, error)
+	GetSaleByIDFunc    func(ctx context.Context, id string) (*models.Sale, error)
+	GetAllSalesFunc    func(ctx context.Context, params *models.SaleListParams) (*models.SalePage, error)
+	SearchSalesFunc    func(ctx context.Context, params *models.SaleSearchParams) ([]*models.SaleSearchResult, error)
+	ExportSalesFunc    func(ctx context.Context, params *models.SaleExportParams, fn func(*models.SaleExportRow) error) error
+	ImportSalesFunc    func(ctx context.Context, r io.Reader, dryRun bool, createdBy *uuid.UUID) (*models.SaleImportReport, error)
+	UpdateSalesFunc    func(ctx context.Context, actor *models.User, id string, req *models.UpdateSaleRequest) (*models.Sale, error)
+	DeleteSalesFunc    func(ctx context.Context, actor *models.User, id string) error
+	GetSaleHistoryFunc func(ctx context.Context, id string) ([]*models.AuditEntry, error)
+	GetTrashFunc       func(ctx context.Context, params *models.SaleListParams) (*models.SalePage, error)
+	RestoreSaleFunc    func(ctx context.Context, actor *models.User, id string) (*models.Sale, error)
+	PurgeTrashFunc     func(ctx context.Context, retention time.Duration) (int64, error)
 }
 
-func (m *MockSaleService) CreateSale(req *models.CreateSalesRequest) (*models.Sale, error) {
+func (m *MockSaleService) CreateSale(ctx context.Context, req *models.CreateSalesRequest) (*models.Sale, error) {
 	if m.CreateSaleFunc != nil {
-		return m.CreateSaleFunc(req)
+		return m.CreateSaleFunc(ctx, req)
 	}
 	return nil, nil
 }
 
-func (m *MockSaleService) GetSaleByID(id string) (*models.Sale, error) {
+func (m *MockSaleService) GetSaleByID(ctx context.Context, id string) (*models.Sale, error) {
 	if m.GetSaleByIDFunc != nil {
-		return m.GetSaleByIDFunc(id)
+		return m.GetSaleByIDFunc(ctx, id)
 	}
 	return nil, nil
 }
 
-func (m *MockSaleService) GetAllSales() ([]*models.Sale, error) {
+func (m *MockSaleService) GetAllSales(ctx context.Context, params *models.SaleListParams) (*models.SalePage, error) {
 	if m.GetAllSalesFunc != nil {
-		return m.GetAllSalesFunc()
+		return m.GetAllSalesFunc(ctx, params)
+	}
+	return nil, nil
+}
+
+func (m *MockSaleService) SearchSales(ctx context.Context, params *models.SaleSearchParams) ([]*models.SaleSearchResult, error) {
+	if m.SearchSalesFunc != nil {
+		return m.SearchSalesFunc(ctx, params)
+	}
+	return nil, nil
+}
+
+func (m *MockSaleService) ExportSales(ctx context.Context, params *models.SaleExportParams, fn func(*models.SaleExportRow) error) error {
+	if m.ExportSalesFunc != nil {
+		return m.ExportSalesFunc(ctx, params, fn)
+	}
+	return nil
+}
+
+func (m *MockSaleService) ImportSales(ctx context.Context, r io.Reader, dryRun bool, createdBy *uuid.UUID) (*models.SaleImportReport, error) {
+	if m.ImportSalesFunc != nil {
+		return m.ImportSalesFunc(ctx, r, dryRun, createdBy)
 	}
 	return nil, nil
 }
 
-func (m *MockSaleService) UpdateSales(id string, req *models.UpdateSaleRequest) (*models.Sale, error) {
+func (m *MockSaleService) UpdateSales(ctx context.Context, actor *models.User, id string, req *models.UpdateSaleRequest) (*models.Sale, error) {
 	if m.UpdateSalesFunc != nil {
-		return m.UpdateSalesFunc(id, req)
+		return m.UpdateSalesFunc(ctx, actor, id, req)
 	}
 	return nil, nil
 }
 
-func (m *MockSaleService) DeleteSales(id string) error {
+func (m *MockSaleService) DeleteSales(ctx context.Context, actor *models.User, id string) error {
 	if m.DeleteSalesFunc != nil {
-		return m.DeleteSalesFunc(id)
+		return m.DeleteSalesFunc(ctx, actor, id)
+	}
+	return nil
+}
+
+func (m *MockSaleService) GetSaleHistory(ctx context.Context, id string) ([]*models.AuditEntry, error) {
+	if m.GetSaleHistoryFunc != nil {
+		return m.GetSaleHistoryFunc(ctx, id)
+	}
+	return nil, nil
+}
+
+func (m *MockSaleService) GetTrash(ctx context.Context, params *models.SaleListParams) (*models.SalePage, error) {
+	if m.GetTrashFunc != nil {
+		return m.GetTrashFunc(ctx, params)
+	}
+	return nil, nil
+}
+
+func (m *MockSaleService) RestoreSale(ctx context.Context, actor *models.User, id string) (*models.Sale, error) {
+	if m.RestoreSaleFunc != nil {
+		return m.RestoreSaleFunc(ctx, actor, id)
+	}
+	return nil, nil
+}
+
+func (m *MockSaleService) PurgeTrash(ctx context.Context, retention time.Duration) (int64, error) {
+	if m.PurgeTrashFunc != nil {
+		return m.PurgeTrashFunc(ctx, retention)
+	}
+	return 0, nil
+}
+
+// MockProductService is a mock implementation of ProductService for testing
+type MockProductService struct {
+	CreateProductFunc  func(ctx context.Context, req *models.CreateProductRequest) (*models.Product, error)
+	GetProductByIDFunc func(ctx context.Context, id string) (*models.Product, error)
+	GetAllProductsFunc func(ctx context.Context, activeOnly bool) ([]*models.Product, error)
+	GetPriceListFunc   func(ctx context.Context) ([]*models.PriceListItem, error)
+	UpdateProductFunc  func(ctx context.Context, id string, req *models.UpdateProductRequest) (*models.Product, error)
+	DeleteProductFunc  func(ctx context.Context, id string) error
+}
+
+func (m *MockProductService) CreateProduct(ctx context.Context, req *models.CreateProductRequest) (*models.Product, error) {
+	if m.CreateProductFunc != nil {
+		return m.CreateProductFunc(ctx, req)
+	}
+	return nil, nil
+}
+
+func (m *MockProductService) GetProductByID(ctx context.Context, id string) (*models.Product, error) {
+	if m.GetProductByIDFunc != nil {
+		return m.GetProductByIDFunc(ctx, id)
+	}
+	return nil, nil
+}
+
+func (m *MockProductService) GetAllProducts(ctx context.Context, activeOnly bool) ([]*models.Product, error) {
+	if m.GetAllProductsFunc != nil {
+		return m.GetAllProductsFunc(ctx, activeOnly)
+	}
+	return nil, nil
+}
+
+func (m *MockProductService) GetPriceList(ctx context.Context) ([]*models.PriceListItem, error) {
+	if m.GetPriceListFunc != nil {
+		return m.GetPriceListFunc(ctx)
+	}
+	return nil, nil
+}
+
+func (m *MockProductService) UpdateProduct(ctx context.Context, id string, req *models.UpdateProductRequest) (*models.Product, error) {
+	if m.UpdateProductFunc != nil {
+		return m.UpdateProductFunc(ctx, id, req)
+	}
+	return nil, nil
+}
+
+func (m *MockProductService) DeleteProduct(ctx context.Context, id string) error {
+	if m.DeleteProductFunc != nil {
+		return m.DeleteProductFunc(ctx, id)
+	}
+	return nil
+}
+
+// MockCustomerService is a mock implementation of CustomerService for testing
+type MockCustomerService struct {
+	CreateCustomerFunc  func(ctx context.Context, req *models.CreateCustomerRequest) (*models.Customer, error)
+	GetCustomerByIDFunc func(ctx context.Context, id string) (*models.Customer, error)
+	GetAllCustomersFunc func(ctx context.Context, q string) ([]*models.Customer, error)
+	UpdateCustomerFunc  func(ctx context.Context, id string, req *models.UpdateCustomerRequest) (*models.Customer, error)
+	DeleteCustomerFunc  func(ctx context.Context, id string) error
+}
+
+func (m *MockCustomerService) CreateCustomer(ctx context.Context, req *models.CreateCustomerRequest) (*models.Customer, error) {
+	if m.CreateCustomerFunc != nil {
+		return m.CreateCustomerFunc(ctx, req)
+	}
+	return nil, nil
+}
+
+func (m *MockCustomerService) GetCustomerByID(ctx context.Context, id string) (*models.Customer, error) {
+	if m.GetCustomerByIDFunc != nil {
+		return m.GetCustomerByIDFunc(ctx, id)
+	}
+	return nil, nil
+}
+
+func (m *MockCustomerService) GetAllCustomers(ctx context.Context, q string) ([]*models.Customer, error) {
+	if m.GetAllCustomersFunc != nil {
+		return m.GetAllCustomersFunc(ctx, q)
+	}
+	return nil, nil
+}
+
+func (m *MockCustomerService) UpdateCustomer(ctx context.Context, id string, req *models.UpdateCustomerRequest) (*models.Customer, error) {
+	if m.UpdateCustomerFunc != nil {
+		return m.UpdateCustomerFunc(ctx, id, req)
+	}
+	return nil, nil
+}
+
+func (m *MockCustomerService) DeleteCustomer(ctx context.Context, id string) error {
+	if m.DeleteCustomerFunc != nil {
+		return m.DeleteCustomerFunc(ctx, id)
+	}
+	return nil
+}
+
+// MockDebtService is a mock implementation of DebtService for testing
+type MockDebtService struct {
+	PaySaleFunc            func(ctx context.Context, saleID string, req *models.CreateDebtPaymentRequest) (*models.SalePaymentResult, error)
+	PayCustomerFunc        func(ctx context.Context, customerID string, req *models.CreateDebtPaymentRequest) (*models.CustomerPaymentResult, error)
+	GetSaleDebtFunc        func(ctx context.Context, saleID string) (*models.SaleDebt, error)
+	GetCustomerBalanceFunc func(ctx context.Context, customerID string) (*models.CustomerBalance, error)
+	GetOpenDebtsFunc       func(ctx context.Context, customerID string) ([]*models.SaleDebt, error)
+}
+
+func (m *MockDebtService) PaySale(ctx context.Context, saleID string, req *models.CreateDebtPaymentRequest) (*models.SalePaymentResult, error) {
+	if m.PaySaleFunc != nil {
+		return m.PaySaleFunc(ctx, saleID, req)
+	}
+	return nil, nil
+}
+
+func (m *MockDebtService) PayCustomer(ctx context.Context, customerID string, req *models.CreateDebtPaymentRequest) (*models.CustomerPaymentResult, error) {
+	if m.PayCustomerFunc != nil {
+		return m.PayCustomerFunc(ctx, customerID, req)
+	}
+	return nil, nil
+}
+
+func (m *MockDebtService) GetSaleDebt(ctx context.Context, saleID string) (*models.SaleDebt, error) {
+	if m.GetSaleDebtFunc != nil {
+		return m.GetSaleDebtFunc(ctx, saleID)
+	}
+	return nil, nil
+}
+
+func (m *MockDebtService) GetCustomerBalance(ctx context.Context, customerID string) (*models.CustomerBalance, error) {
+	if m.GetCustomerBalanceFunc != nil {
+		return m.GetCustomerBalanceFunc(ctx, customerID)
+	}
+	return nil, nil
+}
+
+func (m *MockDebtService) GetOpenDebts(ctx context.Context, customerID string) ([]*models.SaleDebt, error) {
+	if m.GetOpenDebtsFunc != nil {
+		return m.GetOpenDebtsFunc(ctx, customerID)
+	}
+	return nil, nil
+}
+
+// MockReportService is a mock implementation of ReportService for testing
+type MockReportService struct {
+	DebtAgingFunc    func(ctx context.Context) (*models.DebtAgingReport, error)
+	SalesSummaryFunc func(ctx context.Context, params *models.SalesSummaryParams) (*models.SalesSummary, error)
+}
+
+func (m *MockReportService) DebtAging(ctx context.Context) (*models.DebtAgingReport, error) {
+	if m.DebtAgingFunc != nil {
+		return m.DebtAgingFunc(ctx)
+	}
+	return nil, nil
+}
+
+func (m *MockReportService) SalesSummary(ctx context.Context, params *models.SalesSummaryParams) (*models.SalesSummary, error) {
+	if m.SalesSummaryFunc != nil {
+		return m.SalesSummaryFunc(ctx, params)
+	}
+	return nil, nil
+}
+
+// MockAuthService is a mock implementation of AuthService for testing
+type MockAuthService struct {
+	LoginFunc        func(ctx context.Context, req *models.LoginRequest) (*models.AuthTokens, error)
+	RefreshFunc      func(ctx context.Context, refreshToken string) (*models.AuthTokens, error)
+	LogoutFunc       func(ctx context.Context, refreshToken string) error
+	AuthenticateFunc func(ctx context.Context, accessToken string) (*models.User, error)
+	CreateUserFunc   func(ctx context.Context, req *models.CreateUserRequest) (*models.User, error)
+}
+
+func (m *MockAuthService) Login(ctx context.Context, req *models.LoginRequest) (*models.AuthTokens, error) {
+	if m.LoginFunc != nil {
+		return m.LoginFunc(ctx, req)
+	}
+	return nil, nil
+}
+
+func (m *MockAuthService) Refresh(ctx context.Context, refreshToken string) (*models.AuthTokens, error) {
+	if m.RefreshFunc != nil {
+		return m.RefreshFunc(ctx, refreshToken)
+	}
+	return nil, nil
+}
+
+func (m *MockAuthService) Logout(ctx context.Context, refreshToken string) error {
+	if m.LogoutFunc != nil {
+		return m.LogoutFunc(ctx, refreshToken)
+	}
+	return nil
+}
+
+func (m *MockAuthService) Authenticate(ctx context.Context, accessToken string) (*models.User, error) {
+	if m.AuthenticateFunc != nil {
+		return m.AuthenticateFunc(ctx, accessToken)
+	}
+	return nil, nil
+}
+
+func (m *MockAuthService) CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
+	if m.CreateUserFunc != nil {
+		return m.CreateUserFunc(ctx, req)
+	}
+	return nil, nil
+}
+
+// MockIdempotencyService is a mock implementation of IdempotencyService for testing.
+// By default Begin hands out a new claim.
+type MockIdempotencyService struct {
+	BeginFunc        func(ctx context.Context, userID uuid.UUID, key, requestHash string) (*models.IdempotencyKey, error)
+	KeepAliveFunc    func(ctx context.Context, claim *models.IdempotencyKey) func()
+	CompleteFunc     func(ctx context.Context, claim *models.IdempotencyKey, statusCode int, response []byte) error
+	ReleaseFunc      func(ctx context.Context, claim *models.IdempotencyKey) error
+	PurgeExpiredFunc func(ctx context.Context) (int64, error)
+}
+
+func (m *MockIdempotencyService) Begin(ctx context.Context, userID uuid.UUID, key, requestHash string) (*models.IdempotencyKey, error) {
+	if m.BeginFunc != nil {
+		return m.BeginFunc(ctx, userID, key, requestHash)
+	}
+	return &models.IdempotencyKey{UserID: userID, Key: key, RequestHash: requestHash, ClaimToken: uuid.New()}, nil
+}
+
+func (m *MockIdempotencyService) KeepAlive(ctx context.Context, claim *models.IdempotencyKey) func() {
+	if m.KeepAliveFunc != nil {
+		return m.KeepAliveFunc(ctx, claim)
+	}
+	return func() {}
+}
+
+func (m *MockIdempotencyService) Complete(ctx context.Context, claim *models.IdempotencyKey, statusCode int, response []byte) error {
+	if m.CompleteFunc != nil {
+		return m.CompleteFunc(ctx, claim, statusCode, response)
 	}
 	return nil
 }
+
+func (m *MockIdempotencyService) Release(ctx context.Context, claim *models.IdempotencyKey) error {
+	if m.ReleaseFunc != nil {
+		return m.ReleaseFunc(ctx, claim)
+	}
+	return nil
+}
+
+func (m *MockIdempotencyService) PurgeExpired(ctx context.Context) (int64, error) {
+	if m.PurgeExpiredFunc != nil {
+		return m.PurgeExpiredFunc(ctx)
+	}
+	return 0, nil
+}
+
+// MockSaleRecorder is a mock implementation of SaleRecorder for testing
+type MockSaleRecorder struct {
+	SaleRecordedFunc func(source, currency string, total, debt models.Money)
+}
+
+func (m *MockSaleRecorder) SaleRecorded(source, currency string, total, debt models.Money) {
+	if m.SaleRecordedFunc != nil {
+		m.SaleRecordedFunc(source, currency, total, debt)
+	}
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"pencatatan/internal/models"
+	"pencatatan/internal/repository"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrProductNotFound = newError(KindNotFound, "product_not_found", "product not found")
+	ErrProductInactive = newError(KindValidation, "product_inactive", "product is not active")
+	ErrProductExists   = newError(KindConflict, "product_exists", "a product with the same SKU or name already exists")
+	ErrProductInvalid  = newError(KindValidation, "product_invalid", "sku, name and unit must not be blank")
+)
+
+const defaultProductUnit = "pcs"
+
+type ProductService interface {
+	CreateProduct(ctx context.Context, req *models.CreateProductRequest) (*models.Product, error)
+	GetProductByID(ctx context.Context, id string) (*models.Product, error)
+	GetAllProducts(ctx context.Context, activeOnly bool) ([]*models.Product, error)
+	GetPriceList(ctx context.Context) ([]*models.PriceListItem, error)
+	UpdateProduct(ctx context.Context, id string, req *models.UpdateProductRequest) (*models.Product, error)
+	DeleteProduct(ctx context.Context, id string) error
+}
+
+type productService struct {
+	repo repository.ProductRepository
+}
+
+func NewProductService(repo repository.ProductRepository) ProductService {
+	return &productService{
+		repo: repo,
+	}
+}
+
+func (s *productService) CreateProduct(ctx context.Context, req *models.CreateProductRequest) (*models.Product, error) {
+	req.SKU = strings.TrimSpace(req.SKU)
+	req.Name = strings.TrimSpace(req.Name)
+	req.Unit = strings.TrimSpace(req.Unit)
+	if req.SKU == "" || req.Name == "" {
+		return nil, ErrProductInvalid
+	}
+	if req.Unit == "" {
+		req.Unit = defaultProductUnit
+	}
+	if req.IsActive == nil {
+		active := true
+		req.IsActive = &active
+	}
+
+	product, err := s.repo.Create(ctx, req)
+	if errors.Is(err, repository.ErrDuplicate) {
+		return nil, ErrProductExists
+	}
+
+	return product, err
+}
+
+func (s *productService) GetProductByID(ctx context.Context, id string) (*models.Product, error) {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+
+	product, err := s.repo.GetByID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	if product == nil {
+		return nil, ErrProductNotFound
+	}
+
+	return product, nil
+}
+
+func (s *productService) GetAllProducts(ctx context.Context, activeOnly bool) ([]*models.Product, error) {
+	products, err := s.repo.GetAll(ctx, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	if products == nil {
+		products = []*models.Product{}
+	}
+
+	return products, nil
+}
+
+func (s *productService) GetPriceList(ctx context.Context) ([]*models.PriceListItem, error) {
+	products, err := s.repo.GetAll(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*models.PriceListItem, len(products))
+	for i, p := range products {
+		items[i] = &models.PriceListItem{
+			ID:    p.ID,
+			SKU:   p.SKU,
+			Name:  p.Name,
+			Unit:  p.Unit,
+			Price: p.Price,
+		}
+	}
+
+	return items, nil
+}
+
+func (s *productService) UpdateProduct(ctx context.Context, id string, req *models.UpdateProductRequest) (*models.Product, error) {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+
+	for _, field := range []*string{req.SKU, req.Name, req.Unit} {
+		if field != nil {
+			*field = strings.TrimSpace(*field)
+			if *field == "" {
+				return nil, ErrProductInvalid
+			}
+		}
+	}
+
+	product, err := s.repo.Update(ctx, uid, req)
+	if errors.Is(err, repository.ErrDuplicate) {
+		return nil, ErrProductExists
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if product == nil {
+		return nil, ErrProductNotFound
+	}
+
+	return product, nil
+}
+
+func (s *productService) DeleteProduct(ctx context.Context, id string) error {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return ErrInvalidID
+	}
+
+	err = s.repo.Delete(ctx, uid)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrProductNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
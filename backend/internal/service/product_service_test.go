@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"pencatatan/internal/models"
+	"pencatatan/internal/repository"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCreateProduct_Success(t *testing.T) {
+	mockRepo := &repository.MockProductRepository{
+		CreateFunc: func(ctx context.Context, req *models.CreateProductRequest) (*models.Product, error) {
+			return &models.Product{
+				ID:       uuid.New(),
+				SKU:      req.SKU,
+				Name:     req.Name,
+				Unit:     req.Unit,
+				Price:    req.Price,
+				IsActive: *req.IsActive,
+			}, nil
+		},
+	}
+
+	service := NewProductService(mockRepo)
+
+	product, err := service.CreateProduct(context.Background(), &models.CreateProductRequest{
+		SKU:   " KP-001 ",
+		Name:  " Kopi Susu ",
+		Price: models.NewMoney(8000),
+	})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if product.SKU != "KP-001" || product.Name != "Kopi Susu" {
+		t.Errorf("Expected trimmed sku and name, got %q and %q", product.SKU, product.Name)
+	}
+
+	if product.Unit != "pcs" {
+		t.Errorf("Expected default unit pcs, got %s", product.Unit)
+	}
+
+	if !product.IsActive {
+		t.Error("Expected new product to be active")
+	}
+}
+
+func TestCreateProduct_Duplicate(t *testing.T) {
+	mockRepo := &repository.MockProductRepository{
+		CreateFunc: func(ctx context.Context, req *models.CreateProductRequest) (*models.Product, error) {
+			return nil, repository.ErrDuplicate
+		},
+	}
+
+	service := NewProductService(mockRepo)
+
+	_, err := service.CreateProduct(context.Background(), &models.CreateProductRequest{SKU: "KP-001", Name: "Kopi", Price: 100})
+
+	if !errors.Is(err, ErrProductExists) {
+		t.Errorf("Expected product exists error, got %v", err)
+	}
+}
+
+func TestGetProductByID_NotFound(t *testing.T) {
+	mockRepo := &repository.MockProductRepository{}
+	service := NewProductService(mockRepo)
+
+	_, err := service.GetProductByID(context.Background(), uuid.New().String())
+
+	if !errors.Is(err, ErrProductNotFound) {
+		t.Errorf("Expected product not found error, got %v", err)
+	}
+}
+
+func TestGetPriceList_OnlyActive(t *testing.T) {
+	var gotActiveOnly bool
+	mockRepo := &repository.MockProductRepository{
+		GetAllFunc: func(ctx context.Context, activeOnly bool) ([]*models.Product, error) {
+			gotActiveOnly = activeOnly
+			return []*models.Product{
+				{ID: uuid.New(), SKU: "KP-001", Name: "Kopi", Unit: "gelas", Price: models.NewMoney(5000), CostPrice: models.NewMoney(2000)},
+			}, nil
+		},
+	}
+
+	service := NewProductService(mockRepo)
+
+	items, err := service.GetPriceList(context.Background())
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !gotActiveOnly {
+		t.Error("Expected price list to only include active products")
+	}
+
+	if len(items) != 1 || items[0].Price != models.NewMoney(5000) {
+		t.Errorf("Unexpected price list %+v", items)
+	}
+}
+
+func TestUpdateProduct_BlankName(t *testing.T) {
+	mockRepo := &repository.MockProductRepository{}
+	service := NewProductService(mockRepo)
+
+	name := "  "
+	_, err := service.UpdateProduct(context.Background(), uuid.New().String(), &models.UpdateProductRequest{Name: &name})
+
+	if !errors.Is(err, ErrProductInvalid) {
+		t.Errorf("Expected invalid product error, got %v", err)
+	}
+}
+
+func TestDeleteProduct_DatabaseError(t *testing.T) {
+	service := NewProductService(&repository.MockProductRepository{
+		DeleteFunc: func(ctx context.Context, id uuid.UUID) error {
+			return errors.New("connection reset")
+		},
+	})
+
+	err := service.DeleteProduct(context.Background(), uuid.New().String())
+
+	if err == nil || errors.Is(err, ErrProductNotFound) {
+		t.Errorf("Expected the database error to be passed through, got %v", err)
+	}
+}
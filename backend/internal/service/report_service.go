@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"pencatatan/internal/models"
+	"pencatatan/internal/repository"
+	"time"
+)
+
+var ErrSummaryRangeTooLarge = newError(KindValidation, "summary_range_too_large", "summary range has too many periods, use a coarser granularity or a shorter range")
+
+// maxSummaryBuckets caps how many periods one summary may return.
+const maxSummaryBuckets = 366
+
+type ReportService interface {
+	DebtAging(ctx context.Context) (*models.DebtAgingReport, error)
+	SalesSummary(ctx context.Context, params *models.SalesSummaryParams) (*models.SalesSummary, error)
+}
+
+type reportService struct {
+	repo     repository.ReportRepository
+	location *time.Location
+}
+
+// NewReportService creates a ReportService that groups sales into periods in
+// the given business time zone.
+func NewReportService(repo repository.ReportRepository, location *time.Location) ReportService {
+	return &reportService{
+		repo:     repo,
+		location: location,
+	}
+}
+
+func (s *reportService) DebtAging(ctx context.Context) (*models.DebtAgingReport, error) {
+	rows, err := s.repo.DebtAging(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.DebtAgingReport{Customers: rows}
+	if report.Customers == nil {
+		report.Customers = []*models.DebtAgingRow{}
+	}
+
+	for _, row := range rows {
+		report.Totals.Days0To7 += row.Days0To7
+		report.Totals.Days8To30 += row.Days8To30
+		report.Totals.Days31To60 += row.Days31To60
+		report.Totals.Over60Days += row.Over60Days
+		report.Totals.Total += row.Total
+	}
+
+	return report, nil
+}
+
+func (s *reportService) SalesSummary(ctx context.Context, params *models.SalesSummaryParams) (*models.SalesSummary, error) {
+	filter, err := s.newSummaryFilter(params)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets, err := s.repo.SalesSummary(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &models.SalesSummary{
+		Granularity: filter.Granularity,
+		Timezone:    filter.Timezone,
+		From:        filter.From,
+		To:          filter.To,
+		Buckets:     buckets,
+	}
+	if summary.Buckets == nil {
+		summary.Buckets = []*models.SalesSummaryBucket{}
+	}
+
+	for _, bucket := range buckets {
+		summary.Totals.Revenue += bucket.Revenue
+		summary.Totals.Transactions += bucket.Transactions
+		summary.Totals.ItemsSold += bucket.ItemsSold
+		summary.Totals.DebtIssued += bucket.DebtIssued
+		summary.Totals.CashReceived += bucket.CashReceived
+	}
+
+	return summary, nil
+}
+
+// newSummaryFilter resolves the requested range in the business time zone.
+// Without a range the summary ends today and covers the last 30 days, 12
+// weeks or 12 months depending on the granularity.
+func (s *reportService) newSummaryFilter(params *models.SalesSummaryParams) (*models.SalesSummaryFilter, error) {
+	filter := &models.SalesSummaryFilter{
+		Granularity: params.Granularity,
+		Timezone:    s.location.String(),
+	}
+	if filter.Granularity == "" {
+		filter.Granularity = "day"
+	}
+
+	from, err := parseDateParam(params.From, false, s.location)
+	if err != nil {
+		return nil, ErrInvalidDateRange
+	}
+	to, err := parseDateParam(params.To, true, s.location)
+	if err != nil {
+		return nil, ErrInvalidDateRange
+	}
+
+	if to != nil {
+		filter.To = *to
+	} else {
+		now := time.Now().In(s.location)
+		filter.To = time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, s.location)
+	}
+
+	if from != nil {
+		filter.From = *from
+	} else {
+		switch filter.Granularity {
+		case "week":
+			filter.From = filter.To.AddDate(0, 0, -7*12)
+		case "month":
+			filter.From = filter.To.AddDate(0, -12, 0)
+		default:
+			filter.From = filter.To.AddDate(0, 0, -30)
+		}
+	}
+
+	if !filter.From.Before(filter.To) {
+		return nil, ErrInvalidDateRange
+	}
+
+	days := int(filter.To.Sub(filter.From).Hours() / 24)
+	periods := days
+	switch filter.Granularity {
+	case "week":
+		periods = days / 7
+	case "month":
+		periods = days / 31
+	}
+	if periods > maxSummaryBuckets {
+		return nil, ErrSummaryRangeTooLarge
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"pencatatan/internal/models"
+	"pencatatan/internal/repository"
+	"testing"
+	"time"
+)
+
+func TestDebtAging_Totals(t *testing.T) {
+	mockRepo := &repository.MockReportRepository{
+		DebtAgingFunc: func(ctx context.Context) ([]*models.DebtAgingRow, error) {
+			return []*models.DebtAgingRow{
+				{Name: "Pak Budi", OpenSales: 2, DebtAgingBuckets: models.DebtAgingBuckets{
+					Over60Days: models.NewMoney(50000),
+					Days0To7:   models.NewMoney(10000),
+					Total:      models.NewMoney(60000),
+				}},
+				{Name: "Bu Sari", OpenSales: 1, DebtAgingBuckets: models.DebtAgingBuckets{
+					Days8To30: models.NewMoney(25000),
+					Total:     models.NewMoney(25000),
+				}},
+			}, nil
+		},
+	}
+
+	service := NewReportService(mockRepo, time.UTC)
+
+	report, err := service.DebtAging(context.Background())
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(report.Customers) != 2 {
+		t.Errorf("Expected 2 customers, got %d", len(report.Customers))
+	}
+
+	if report.Totals.Total != models.NewMoney(85000) {
+		t.Errorf("Expected total 85000.00, got %s", report.Totals.Total)
+	}
+
+	if report.Totals.Over60Days != models.NewMoney(50000) || report.Totals.Days8To30 != models.NewMoney(25000) {
+		t.Errorf("Unexpected bucket totals %+v", report.Totals)
+	}
+}
+
+func TestDebtAging_Empty(t *testing.T) {
+	service := NewReportService(&repository.MockReportRepository{}, time.UTC)
+
+	report, err := service.DebtAging(context.Background())
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if report.Customers == nil {
+		t.Error("Expected an empty customer list instead of nil")
+	}
+}
+
+func TestDebtAging_RepositoryError(t *testing.T) {
+	mockRepo := &repository.MockReportRepository{
+		DebtAgingFunc: func(ctx context.Context) ([]*models.DebtAgingRow, error) {
+			return nil, errors.New("db down")
+		},
+	}
+
+	service := NewReportService(mockRepo, time.UTC)
+
+	if _, err := service.DebtAging(context.Background()); err == nil {
+		t.Error("Expected repository error to be returned")
+	}
+}
+
+func TestSalesSummary_Range(t *testing.T) {
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+
+	var gotFilter *models.SalesSummaryFilter
+	mockRepo := &repository.MockReportRepository{
+		SalesSummaryFunc: func(ctx context.Context, filter *models.SalesSummaryFilter) ([]*models.SalesSummaryBucket, error) {
+			gotFilter = filter
+			return []*models.SalesSummaryBucket{
+				{Period: "2026-10-01", SalesSummaryFigures: models.SalesSummaryFigures{
+					Revenue: models.NewMoney(50000), Transactions: 2, ItemsSold: 5, CashReceived: models.NewMoney(40000),
+				}},
+				{Period: "2026-10-02", SalesSummaryFigures: models.SalesSummaryFigures{
+					Revenue: models.NewMoney(20000), Transactions: 1, ItemsSold: 1, DebtIssued: models.NewMoney(20000),
+				}},
+			}, nil
+		},
+	}
+
+	service := NewReportService(mockRepo, jakarta)
+
+	summary, err := service.SalesSummary(context.Background(), &models.SalesSummaryParams{From: "2026-10-01", To: "2026-10-02"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if gotFilter.Granularity != "day" || gotFilter.Timezone != "Asia/Jakarta" {
+		t.Errorf("Unexpected filter %+v", gotFilter)
+	}
+
+	wantFrom := time.Date(2026, 10, 1, 0, 0, 0, 0, jakarta)
+	wantTo := time.Date(2026, 10, 3, 0, 0, 0, 0, jakarta)
+	if !gotFilter.From.Equal(wantFrom) || !gotFilter.To.Equal(wantTo) {
+		t.Errorf("Expected range %v - %v, got %v - %v", wantFrom, wantTo, gotFilter.From, gotFilter.To)
+	}
+
+	if summary.Totals.Revenue != models.NewMoney(70000) || summary.Totals.Transactions != 3 || summary.Totals.ItemsSold != 6 {
+		t.Errorf("Unexpected totals %+v", summary.Totals)
+	}
+}
+
+func TestSalesSummary_DefaultRange(t *testing.T) {
+	var gotFilter *models.SalesSummaryFilter
+	mockRepo := &repository.MockReportRepository{
+		SalesSummaryFunc: func(ctx context.Context, filter *models.SalesSummaryFilter) ([]*models.SalesSummaryBucket, error) {
+			gotFilter = filter
+			return nil, nil
+		},
+	}
+
+	service := NewReportService(mockRepo, time.UTC)
+
+	summary, err := service.SalesSummary(context.Background(), &models.SalesSummaryParams{Granularity: "month"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !gotFilter.From.Equal(gotFilter.To.AddDate(0, -12, 0)) {
+		t.Errorf("Expected a 12 month range, got %v - %v", gotFilter.From, gotFilter.To)
+	}
+
+	if summary.Buckets == nil {
+		t.Error("Expected an empty bucket list instead of nil")
+	}
+}
+
+func TestSalesSummary_InvalidRange(t *testing.T) {
+	service := NewReportService(&repository.MockReportRepository{}, time.UTC)
+
+	if _, err := service.SalesSummary(context.Background(), &models.SalesSummaryParams{From: "2026-10-05", To: "2026-10-01"}); !errors.Is(err, ErrInvalidDateRange) {
+		t.Errorf("Expected invalid date range error, got %v", err)
+	}
+
+	if _, err := service.SalesSummary(context.Background(), &models.SalesSummaryParams{From: "2020-01-01", To: "2026-01-01"}); !errors.Is(err, ErrSummaryRangeTooLarge) {
+		t.Errorf("Expected range too large error, got %v", err)
+	}
+}
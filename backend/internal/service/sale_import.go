@@ -0,0 +1,418 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"pencatatan/internal/models"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+var ErrInvalidImportFile = newError(KindValidation, "invalid_import_file", "invalid import file")
+
+// importBatchSize is how many sales are written per transaction. A failing
+// batch only loses its own sales; earlier batches stay saved.
+const importBatchSize = 500
+
+// importColumnAliases lets a file use Indonesian column titles, as found in
+// the sales export and in most hand-made spreadsheets.
+var importColumnAliases = map[string]string{
+	"tanggal":       "transaction_date",
+	"no._transaksi": "receipt",
+	"pelanggan":     "name",
+	"produk":        "product",
+	"jumlah":        "quantity",
+	"harga":         "price",
+	"dibayar":       "amount_received",
+	"status":        "is_debt",
+	"mata_uang":     "currency",
+}
+
+// importFieldColumns maps request fields reported by the validator to the CSV
+// column a user has to fix.
+var importFieldColumns = map[string]string{
+	"Name":            "name",
+	"CustomerID":      "customer_id",
+	"Items":           "product",
+	"ProductID":       "product_id",
+	"Product":         "product",
+	"Quantity":        "quantity",
+	"Price":           "price",
+	"AmountReceived":  "amount_received",
+	"Currency":        "currency",
+	"IsDebt":          "is_debt",
+	"TransactionDate": "transaction_date",
+}
+
+var importItemIndex = regexp.MustCompile(`Items\[(\d+)\]`)
+
+var importDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	time.DateOnly,
+	"02/01/2006 15:04:05",
+	"02/01/2006 15:04",
+	"02/01/2006",
+}
+
+// importSale is one sale being assembled from one or more CSV lines.
+type importSale struct {
+	lines  []int
+	req    *models.CreateSalesRequest
+	failed bool
+}
+
+// ImportSales loads sales from CSV. The header names the columns:
+// transaction_date, receipt, name, customer_id, product, product_id,
+// quantity, price, amount_received, is_debt and currency. Lines that share a
+// receipt value become the items of one sale. Every sale is checked with the
+// same validation and business rules as CreateSale; valid sales are saved in
+// batches unless dryRun is set, and problems are reported per line. Imported
+// sales are recorded as created by createdBy.
+func (s *saleService) ImportSales(ctx context.Context, r io.Reader, dryRun bool, createdBy *uuid.UUID) (*models.SaleImportReport, error) {
+	reader, columns, err := newImportReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.SaleImportReport{DryRun: dryRun, Errors: []models.SaleImportError{}}
+	reject := func(line int, column string, err error) {
+		report.Errors = append(report.Errors, models.SaleImportError{Line: line, Column: column, Error: err.Error()})
+	}
+
+	var sales []*importSale
+	receipts := make(map[string]*importSale)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		// FieldPos is only valid after a successful Read.
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			report.Rows++
+			reject(parseErr.StartLine, "", parseErr.Err)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		report.Rows++
+		line, _ := reader.FieldPos(0)
+
+		values := make(map[string]string, len(columns))
+		for i, column := range columns {
+			if i < len(record) && column != "" {
+				values[column] = strings.TrimSpace(record[i])
+			}
+		}
+
+		receipt := values["receipt"]
+		sale := receipts[receipt]
+		if sale == nil {
+			sale = &importSale{req: &models.CreateSalesRequest{CreatedBy: createdBy}}
+			sales = append(sales, sale)
+			if receipt != "" {
+				receipts[receipt] = sale
+			}
+		}
+		sale.lines = append(sale.lines, line)
+
+		// The sale header is taken from the first line of a receipt.
+		var lineErrs []models.SaleImportError
+		if len(sale.lines) == 1 {
+			lineErrs = s.parseImportHeader(values, sale.req)
+		}
+		item, itemErrs := parseImportItem(values)
+		lineErrs = append(lineErrs, itemErrs...)
+		sale.req.Items = append(sale.req.Items, item)
+
+		for _, lineErr := range lineErrs {
+			lineErr.Line = line
+			report.Errors = append(report.Errors, lineErr)
+			sale.failed = true
+		}
+	}
+
+	report.Sales = len(sales)
+
+	var checked []*importSale
+	for _, sale := range sales {
+		if sale.failed {
+			continue
+		}
+
+		if err := binding.Validator.ValidateStruct(sale.req); err != nil {
+			var fieldErrs validator.ValidationErrors
+			if !errors.As(err, &fieldErrs) {
+				return nil, err
+			}
+			for _, fieldErr := range fieldErrs {
+				line, column := sale.fieldLine(fieldErr)
+				reject(line, column, fmt.Errorf("failed on the '%s' rule", fieldErr.Tag()))
+			}
+			continue
+		}
+
+		checked = append(checked, sale)
+	}
+
+	// Each batch is checked and saved in one transaction, like CreateSale, so
+	// the customers it adds debt for stay locked until it is saved. pending
+	// counts the debt of sales accepted but not yet saved; a dry run saves
+	// nothing, so there it covers the whole file.
+	pending := make(map[uuid.UUID]models.Money)
+	for start := 0; start < len(checked); start += importBatchSize {
+		batch := checked[start:min(start+importBatchSize, len(checked))]
+		if !dryRun {
+			clear(pending)
+		}
+
+		var reqs []*models.CreateSalesRequest
+		var valid []*importSale
+		var ruleErrs []models.SaleImportError
+		var failed error
+		err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+			for _, sale := range batch {
+				if err := s.prepareSale(ctx, sale.req, pending); err != nil {
+					if !isSaleRuleError(err) {
+						failed = err
+						return err
+					}
+					ruleErrs = append(ruleErrs, models.SaleImportError{Line: sale.lines[0], Error: err.Error()})
+					continue
+				}
+				valid = append(valid, sale)
+				reqs = append(reqs, sale.req)
+			}
+
+			if dryRun || len(reqs) == 0 {
+				return nil
+			}
+			return s.repo.CreateBatch(ctx, reqs)
+		})
+		if failed != nil {
+			return nil, failed
+		}
+
+		report.Errors = append(report.Errors, ruleErrs...)
+		report.Valid += len(valid)
+		if dryRun {
+			continue
+		}
+
+		if err != nil {
+			for _, sale := range valid {
+				reject(sale.lines[0], "", fmt.Errorf("not saved: %w", err))
+			}
+			continue
+		}
+		report.Imported += len(valid)
+
+		for _, req := range reqs {
+			total, _ := models.ItemsTotal(req.Items)
+			s.recorder.SaleRecorded(SaleSourceImport, req.Currency, total, unpaid(total, req.AmountReceived, req.IsDebt))
+		}
+	}
+
+	report.Failed = report.Sales - report.Valid
+	if !dryRun {
+		report.Failed = report.Sales - report.Imported
+	}
+
+	return report, nil
+}
+
+// newImportReader reads the header line, guessing whether the file uses
+// commas or semicolons, and returns the canonical column names in order.
+func newImportReader(r io.Reader) (*csv.Reader, []string, error) {
+	buffered := bufio.NewReader(r)
+	first, err := buffered.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, nil, err
+	}
+	first = strings.TrimPrefix(first, "\uFEFF")
+	if strings.TrimSpace(first) == "" {
+		return nil, nil, fmt.Errorf("%w: missing header line", ErrInvalidImportFile)
+	}
+
+	reader := csv.NewReader(io.MultiReader(strings.NewReader(first), buffered))
+	if strings.Count(first, ";") > strings.Count(first, ",") {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidImportFile, err)
+	}
+
+	columns := make([]string, len(header))
+	found := make(map[string]bool)
+	for i, title := range header {
+		column := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(title)), " ", "_")
+		if alias, ok := importColumnAliases[column]; ok {
+			column = alias
+		}
+		columns[i] = column
+		found[column] = true
+	}
+
+	if !found["quantity"] {
+		return nil, nil, fmt.Errorf("%w: missing column %q", ErrInvalidImportFile, "quantity")
+	}
+	if !found["product"] && !found["product_id"] {
+		return nil, nil, fmt.Errorf("%w: missing column %q or %q", ErrInvalidImportFile, "product", "product_id")
+	}
+
+	return reader, columns, nil
+}
+
+func (s *saleService) parseImportHeader(values map[string]string, req *models.CreateSalesRequest) []models.SaleImportError {
+	var errs []models.SaleImportError
+	fail := func(column string, err error) {
+		errs = append(errs, models.SaleImportError{Column: column, Error: err.Error()})
+	}
+
+	req.Name = values["name"]
+	req.Currency = strings.ToUpper(values["currency"])
+
+	if v := values["customer_id"]; v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			fail("customer_id", ErrInvalidID)
+		} else {
+			req.CustomerID = &id
+		}
+	}
+
+	if v := values["amount_received"]; v != "" {
+		amount, err := parseImportMoney(v)
+		if err != nil {
+			fail("amount_received", err)
+		}
+		req.AmountReceived = amount
+	}
+
+	if v := values["is_debt"]; v != "" {
+		isDebt, err := parseImportBool(v)
+		if err != nil {
+			fail("is_debt", err)
+		}
+		req.IsDebt = isDebt
+	}
+
+	if v := values["transaction_date"]; v != "" {
+		date, err := s.parseImportDate(v)
+		if err != nil {
+			fail("transaction_date", err)
+		} else {
+			req.TransactionDate = &date
+		}
+	}
+
+	return errs
+}
+
+func parseImportItem(values map[string]string) (models.SaleItemRequest, []models.SaleImportError) {
+	var errs []models.SaleImportError
+	fail := func(column string, err error) {
+		errs = append(errs, models.SaleImportError{Column: column, Error: err.Error()})
+	}
+
+	item := models.SaleItemRequest{Product: values["product"]}
+
+	if v := values["product_id"]; v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			fail("product_id", ErrInvalidID)
+		} else {
+			item.ProductID = &id
+		}
+	}
+
+	if v := values["quantity"]; v != "" {
+		quantity, err := strconv.Atoi(v)
+		if err != nil {
+			fail("quantity", fmt.Errorf("invalid quantity %q", v))
+		}
+		item.Quantity = quantity
+	}
+
+	if v := values["price"]; v != "" {
+		price, err := parseImportMoney(v)
+		if err != nil {
+			fail("price", err)
+		}
+		item.Price = price
+	}
+
+	return item, errs
+}
+
+// parseImportMoney accepts plain amounts such as "12500", "12500.50" or, as
+// written with Indonesian settings, "Rp 12500,50". Thousands separators are
+// rejected because "12.500" cannot be told apart from twelve and a half.
+func parseImportMoney(v string) (models.Money, error) {
+	v = strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(v, "Rp"), "rp"))
+	if strings.Count(v, ",") == 1 && !strings.Contains(v, ".") {
+		v = strings.Replace(v, ",", ".", 1)
+	}
+	return models.ParseMoney(v)
+}
+
+func parseImportBool(v string) (bool, error) {
+	switch strings.ToLower(v) {
+	case "1", "true", "yes", "ya", "hutang":
+		return true, nil
+	case "0", "false", "no", "tidak", "lunas":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid boolean %q", v)
+}
+
+// parseImportDate reads dates and times without an offset in the business
+// time zone.
+func (s *saleService) parseImportDate(v string) (time.Time, error) {
+	for _, layout := range importDateLayouts {
+		if t, err := time.ParseInLocation(layout, v, s.location); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q, use YYYY-MM-DD or DD/MM/YYYY with an optional time", v)
+}
+
+// fieldLine finds the CSV line and column a validation error belongs to.
+// Item errors point at the line of that item, header errors at the first line.
+func (sale *importSale) fieldLine(fieldErr validator.FieldError) (int, string) {
+	line := sale.lines[0]
+	if m := importItemIndex.FindStringSubmatch(fieldErr.Namespace()); m != nil {
+		if i, err := strconv.Atoi(m[1]); err == nil && i < len(sale.lines) {
+			line = sale.lines[i]
+		}
+	}
+	return line, importFieldColumns[fieldErr.Field()]
+}
+
+// isSaleRuleError reports whether err is a business rule violation rather than
+// a failure talking to the database.
+func isSaleRuleError(err error) bool {
+	return errors.Is(err, ErrInsufficientAmount) ||
+		errors.Is(err, ErrProductNotFound) ||
+		errors.Is(err, ErrProductInactive) ||
+		errors.Is(err, ErrCustomerNotFound) ||
+		errors.Is(err, ErrCreditLimitExceeded)
+}
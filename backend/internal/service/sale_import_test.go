@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"pencatatan/internal/models"
+	"pencatatan/internal/repository"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestImportSales_Success(t *testing.T) {
+	var saved []*models.CreateSalesRequest
+	mockRepo := &repository.MockSaleRepository{
+		CreateBatchFunc: func(ctx context.Context, sales []*models.CreateSalesRequest) error {
+			saved = append(saved, sales...)
+			return nil
+		},
+	}
+
+	service := newTestSaleService(mockRepo)
+
+	csv := "transaction_date;receipt;name;product;quantity;price;amount_received;is_debt\n" +
+		"2024-03-01;A1;Bu Sari;Beras;2;12000;40000;\n" +
+		"2024-03-01;A1;Bu Sari;Gula;1;15000,50;;\n" +
+		"02/03/2024 10:15;;Pak Budi;Telur;10;2000;;hutang\n"
+
+	report, err := service.ImportSales(context.Background(), strings.NewReader(csv), false, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if report.Rows != 3 || report.Sales != 2 || report.Imported != 2 || report.Failed != 0 {
+		t.Errorf("Unexpected report %+v", report)
+	}
+
+	if len(saved) != 2 || len(saved[0].Items) != 2 {
+		t.Fatalf("Expected receipt A1 saved with 2 items, got %+v", saved)
+	}
+
+	if saved[0].Items[1].Price != models.Money(1500050) {
+		t.Errorf("Expected decimal comma price 15000.50, got %s", saved[0].Items[1].Price)
+	}
+
+	if !saved[1].IsDebt || saved[1].TransactionDate.Hour() != 10 || saved[1].Currency != models.DefaultCurrency {
+		t.Errorf("Unexpected second sale %+v", saved[1])
+	}
+}
+
+func TestImportSales_RowErrors(t *testing.T) {
+	mockRepo := &repository.MockSaleRepository{
+		CreateBatchFunc: func(ctx context.Context, sales []*models.CreateSalesRequest) error {
+			if len(sales) != 1 {
+				t.Errorf("Expected only the valid sale to be saved, got %d", len(sales))
+			}
+			return nil
+		},
+	}
+
+	service := newTestSaleService(mockRepo)
+
+	csv := "product,quantity,price,amount_received,currency\n" +
+		"Beras,1,12000,12000,\n" +
+		"Gula,0,15000,15000,\n" +
+		"Teh,1,abc,5000,\n" +
+		"Kopi,2,5000,1000,\n" +
+		"Susu,1,7000,7000,XYZ\n"
+
+	report, err := service.ImportSales(context.Background(), strings.NewReader(csv), false, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if report.Sales != 5 || report.Valid != 1 || report.Imported != 1 || report.Failed != 4 {
+		t.Errorf("Unexpected report %+v", report)
+	}
+
+	want := map[int]string{3: "quantity", 4: "price", 5: "", 6: "currency"}
+	if len(report.Errors) != len(want) {
+		t.Fatalf("Expected %d errors, got %+v", len(want), report.Errors)
+	}
+	for _, rowErr := range report.Errors {
+		column, ok := want[rowErr.Line]
+		if !ok || column != rowErr.Column {
+			t.Errorf("Unexpected error %+v", rowErr)
+		}
+	}
+}
+
+func TestImportSales_DryRun(t *testing.T) {
+	mockRepo := &repository.MockSaleRepository{
+		CreateBatchFunc: func(ctx context.Context, sales []*models.CreateSalesRequest) error {
+			t.Error("Expected nothing to be saved in a dry run")
+			return nil
+		},
+	}
+
+	service := newTestSaleService(mockRepo)
+
+	report, err := service.ImportSales(context.Background(), strings.NewReader("product,quantity,price,amount_received\nBeras,1,12000,12000\n"), true, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !report.DryRun || report.Valid != 1 || report.Imported != 0 || report.Failed != 0 {
+		t.Errorf("Unexpected report %+v", report)
+	}
+}
+
+func TestImportSales_BatchError(t *testing.T) {
+	mockRepo := &repository.MockSaleRepository{
+		CreateBatchFunc: func(ctx context.Context, sales []*models.CreateSalesRequest) error {
+			return errors.New("connection reset")
+		},
+	}
+
+	service := newTestSaleService(mockRepo)
+
+	report, err := service.ImportSales(context.Background(), strings.NewReader("product,quantity,price,amount_received\nBeras,1,12000,12000\n"), false, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if report.Imported != 0 || report.Failed != 1 || len(report.Errors) != 1 || report.Errors[0].Line != 2 {
+		t.Errorf("Unexpected report %+v", report)
+	}
+}
+
+func TestImportSales_InvalidFile(t *testing.T) {
+	service := newTestSaleService(&repository.MockSaleRepository{})
+
+	for _, csv := range []string{"", "name,price\nBu Sari,1000\n"} {
+		if _, err := service.ImportSales(context.Background(), strings.NewReader(csv), false, nil); !errors.Is(err, ErrInvalidImportFile) {
+			t.Errorf("Expected invalid import file error for %q, got %v", csv, err)
+		}
+	}
+}
+
+func TestImportSales_MalformedLine(t *testing.T) {
+	mockRepo := &repository.MockSaleRepository{
+		CreateBatchFunc: func(ctx context.Context, sales []*models.CreateSalesRequest) error {
+			return nil
+		},
+	}
+
+	service := newTestSaleService(mockRepo)
+
+	csv := "product,quantity,price,amount_received\n" +
+		"Be\"ras,1,12000,12000\n" +
+		"Gula,1,15000,15000\n"
+
+	report, err := service.ImportSales(context.Background(), strings.NewReader(csv), false, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if report.Rows != 2 || report.Imported != 1 || len(report.Errors) != 1 || report.Errors[0].Line != 2 {
+		t.Errorf("Unexpected report %+v", report)
+	}
+}
+
+func TestImportSales_CreditLimitAcrossRows(t *testing.T) {
+	customerID := uuid.New()
+	var saved []*models.CreateSalesRequest
+	mockRepo := &repository.MockSaleRepository{
+		CreateBatchFunc: func(ctx context.Context, sales []*models.CreateSalesRequest) error {
+			saved = append(saved, sales...)
+			return nil
+		},
+	}
+	customerRepo := &repository.MockCustomerRepository{
+		GetByIDFunc: func(ctx context.Context, id uuid.UUID) (*models.Customer, error) {
+			return &models.Customer{ID: id, Name: "Bu Sari", CreditLimit: models.NewMoney(30000)}, nil
+		},
+	}
+
+	service := NewSaleService(mockRepo, &repository.MockProductRepository{}, customerRepo, &repository.MockDebtRepository{}, &repository.MockTxManager{}, &MockSaleRecorder{}, time.UTC)
+
+	csv := "customer_id,product,quantity,price,amount_received,is_debt\n" +
+		customerID.String() + ",Beras,1,20000,0,hutang\n" +
+		customerID.String() + ",Gula,1,20000,0,hutang\n"
+
+	for _, dryRun := range []bool{true, false} {
+		saved = nil
+		report, err := service.ImportSales(context.Background(), strings.NewReader(csv), dryRun, nil)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if report.Valid != 1 || len(report.Errors) != 1 || report.Errors[0].Line != 3 {
+			t.Errorf("Expected the second debt to break the credit limit (dry run %t), got %+v", dryRun, report)
+		}
+		if !dryRun && len(saved) != 1 {
+			t.Errorf("Expected only the first sale to be saved, got %d", len(saved))
+		}
+	}
+}
@@ -1,91 +1,656 @@
 package service
 
 import (
+	"context"
+	"database/sql"
 	"errors"
+	"io"
 	"pencatatan/internal/models"
 	"pencatatan/internal/repository"
+	"strings"
+	"time"
+	"unicode"
 
 	"github.com/google/uuid"
 )
 
+var (
+	ErrInvalidID          = newError(KindValidation, "invalid_id", "invalid UUID format")
+	ErrSaleNotFound       = newError(KindNotFound, "sale_not_found", "sale not found")
+	ErrInsufficientAmount = newError(KindValidation, "insufficient_amount", "amount received is less than total price")
+	ErrEmptySearchQuery   = newError(KindValidation, "empty_search_query", "search query must contain a letter or digit")
+	ErrInvalidDateRange   = newError(KindValidation, "invalid_date_range", "invalid date range, use YYYY-MM-DD or RFC 3339 with from before to")
+	ErrSaleModified       = newError(KindPreconditionFailed, "sale_modified", "sale was changed by someone else, reload it and try again")
+	ErrNullSaleField      = newError(KindValidation, "null_sale_field", "amount_received and is_debt cannot be null")
+	ErrNegativeAmount     = newError(KindValidation, "negative_amount", "amount received must not be negative")
+	ErrTotalTooLarge      = newError(KindValidation, "total_too_large", "sale total is too large")
+)
+
+// Sources of recorded sales.
+const (
+	SaleSourceAPI    = "api"
+	SaleSourceImport = "import"
+)
+
+// SaleRecorder is told about every sale saved, e.g. to count it in metrics.
+// Debt is the part of total left unpaid.
+type SaleRecorder interface {
+	SaleRecorded(source, currency string, total, debt models.Money)
+}
+
 type SaleService interface {
-	CreateSale(req *models.CreateSalesRequest) (*models.Sale, error)
-	GetSaleByID(id string) (*models.Sale, error)
-	GetAllSales() ([]*models.Sale, error)
-	UpdateSales(id string, req *models.UpdateSaleRequest) (*models.Sale, error)
-	DeleteSales(id string) error
+	CreateSale(ctx context.Context, req *models.CreateSalesRequest) (*models.Sale, error)
+	GetSaleByID(ctx context.Context, id string) (*models.Sale, error)
+	GetAllSales(ctx context.Context, params *models.SaleListParams) (*models.SalePage, error)
+	SearchSales(ctx context.Context, params *models.SaleSearchParams) ([]*models.SaleSearchResult, error)
+	ExportSales(ctx context.Context, params *models.SaleExportParams, fn func(*models.SaleExportRow) error) error
+	ImportSales(ctx context.Context, r io.Reader, dryRun bool, createdBy *uuid.UUID) (*models.SaleImportReport, error)
+	UpdateSales(ctx context.Context, actor *models.User, id string, req *models.UpdateSaleRequest) (*models.Sale, error)
+	DeleteSales(ctx context.Context, actor *models.User, id string) error
+	GetSaleHistory(ctx context.Context, id string) ([]*models.AuditEntry, error)
+	GetTrash(ctx context.Context, params *models.SaleListParams) (*models.SalePage, error)
+	RestoreSale(ctx context.Context, actor *models.User, id string) (*models.Sale, error)
+	PurgeTrash(ctx context.Context, retention time.Duration) (int64, error)
 }
 
 type saleService struct {
-	repo repository.SaleRepository
+	repo         repository.SaleRepository
+	productRepo  repository.ProductRepository
+	customerRepo repository.CustomerRepository
+	debtRepo     repository.DebtRepository
+	tx           repository.TxManager
+	recorder     SaleRecorder
+	location     *time.Location
 }
 
-func NewSaleService(repo repository.SaleRepository) SaleService {
+// NewSaleService creates a SaleService. Plain dates in filters are read in the
+// given business time zone.
+func NewSaleService(
+	repo repository.SaleRepository,
+	productRepo repository.ProductRepository,
+	customerRepo repository.CustomerRepository,
+	debtRepo repository.DebtRepository,
+	tx repository.TxManager,
+	recorder SaleRecorder,
+	location *time.Location,
+) SaleService {
 	return &saleService{
-		repo: repo,
+		repo:         repo,
+		productRepo:  productRepo,
+		customerRepo: customerRepo,
+		debtRepo:     debtRepo,
+		tx:           tx,
+		recorder:     recorder,
+		location:     location,
 	}
 }
 
-func (s *saleService) CreateSale(req *models.CreateSalesRequest) (*models.Sale, error) {
-	return s.repo.Create(req)
+// CreateSale checks and saves the sale in one transaction, so a debt sale
+// holds its customer's credit limit until it is saved.
+func (s *saleService) CreateSale(ctx context.Context, req *models.CreateSalesRequest) (*models.Sale, error) {
+	var sale *models.Sale
+	err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.prepareSale(ctx, req, nil); err != nil {
+			return err
+		}
+
+		var err error
+		sale, err = s.repo.Create(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.recorder.SaleRecorded(SaleSourceAPI, sale.Currency, sale.Total, unpaid(sale.Total, sale.AmountReceived, sale.IsDebt))
+
+	return sale, nil
+}
+
+// unpaid returns the part of a sale's total left as debt.
+func unpaid(total, amountReceived models.Money, isDebt bool) models.Money {
+	if !isDebt || amountReceived >= total {
+		return 0
+	}
+	return total - amountReceived
+}
+
+// prepareSale applies the business rules every new sale must pass: it fills in
+// customer and catalog data, checks the amount paid and the credit limit, and
+// defaults the currency. pending, when not nil, holds debt already accepted
+// for customers but not yet saved; the debt of this sale is added to it.
+func (s *saleService) prepareSale(ctx context.Context, req *models.CreateSalesRequest, pending map[uuid.UUID]models.Money) error {
+	var customer *models.Customer
+	if req.CustomerID != nil {
+		var err error
+		customer, err = s.customer(ctx, *req.CustomerID)
+		if err != nil {
+			return err
+		}
+		req.Name = customer.Name
+	}
+
+	if err := s.resolveItems(ctx, req.Items); err != nil {
+		return err
+	}
+
+	total, err := models.ItemsTotal(req.Items)
+	if err != nil {
+		return ErrTotalTooLarge
+	}
+	if !req.IsDebt && req.AmountReceived < total {
+		return ErrInsufficientAmount
+	}
+
+	if debt := unpaid(total, req.AmountReceived, req.IsDebt); debt > 0 && customer != nil {
+		if err := s.checkCreditLimit(ctx, customer, pending[customer.ID]+debt); err != nil {
+			return err
+		}
+		if pending != nil {
+			pending[customer.ID] += debt
+		}
+	}
+
+	if req.Currency == "" {
+		req.Currency = models.DefaultCurrency
+	}
+
+	return nil
 }
 
-func (s *saleService) GetSaleByID(id string) (*models.Sale, error) {
+func (s *saleService) GetSaleByID(ctx context.Context, id string) (*models.Sale, error) {
 	uid, err := uuid.Parse(id)
 	if err != nil {
-		return nil, errors.New("invalid UUID format")
+		return nil, ErrInvalidID
 	}
 
-	sale, err := s.repo.GetByID(uid)
+	sale, err := s.repo.GetByID(ctx, uid)
 	if err != nil {
 		return nil, err
 	}
 
 	if sale == nil {
-		return nil, errors.New("sale not found")
+		return nil, ErrSaleNotFound
 	}
 
 	return sale, nil
 }
 
-func (s *saleService) GetAllSales() ([]*models.Sale, error) {
-	return s.repo.GetAll()
+func (s *saleService) GetAllSales(ctx context.Context, params *models.SaleListParams) (*models.SalePage, error) {
+	filter, err := newSaleFilter(params, s.location)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.salePage(ctx, filter)
+}
+
+// GetTrash lists deleted sales that can still be restored, most recently
+// deleted first unless another sort is given.
+func (s *saleService) GetTrash(ctx context.Context, params *models.SaleListParams) (*models.SalePage, error) {
+	filter, err := newSaleFilter(params, s.location)
+	if err != nil {
+		return nil, err
+	}
+
+	filter.Deleted = true
+	if params.Sort == "" {
+		filter.Sort = "deleted_at"
+	}
+
+	return s.salePage(ctx, filter)
+}
+
+func (s *saleService) salePage(ctx context.Context, filter *models.SaleFilter) (*models.SalePage, error) {
+	// A cursor from a list in another order would compare the wrong column.
+	if filter.After != nil && (filter.After.Sort != filter.Sort || filter.After.Desc != filter.Desc) {
+		return nil, invalid("invalid_cursor", models.ErrInvalidCursor)
+	}
+
+	// Fetch one extra row to know whether another page exists.
+	filter.Limit++
+	sales, total, err := s.repo.GetAll(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	filter.Limit--
+
+	meta := models.PageMeta{
+		Limit:      filter.Limit,
+		Total:      total,
+		TotalPages: int((total + int64(filter.Limit) - 1) / int64(filter.Limit)),
+	}
+	if filter.After == nil {
+		meta.Page = filter.Offset/filter.Limit + 1
+	}
+	if len(sales) > filter.Limit {
+		sales = sales[:filter.Limit]
+		last := sales[len(sales)-1]
+		meta.HasMore = true
+		meta.NextCursor = models.Cursor{
+			Value: last.SortValue(filter.Sort),
+			ID:    last.ID,
+			Sort:  filter.Sort,
+			Desc:  filter.Desc,
+		}.Encode()
+	}
+	if sales == nil {
+		sales = []*models.Sale{}
+	}
+
+	return &models.SalePage{Sales: sales, Meta: meta}, nil
+}
+
+func newSaleFilter(params *models.SaleListParams, location *time.Location) (*models.SaleFilter, error) {
+	filter := &models.SaleFilter{
+		IsDebt:  params.IsDebt,
+		Product: strings.TrimSpace(params.Product),
+		Name:    strings.TrimSpace(params.Name),
+		Sort:    params.Sort,
+		Desc:    params.Order != "asc",
+		Limit:   params.Limit,
+	}
+	if filter.Sort == "" {
+		filter.Sort = "transaction_date"
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = models.DefaultPageLimit
+	}
+	if filter.Limit > models.MaxPageLimit {
+		filter.Limit = models.MaxPageLimit
+	}
+
+	if params.CustomerID != "" {
+		customerID, err := uuid.Parse(params.CustomerID)
+		if err != nil {
+			return nil, ErrInvalidID
+		}
+		filter.CustomerID = &customerID
+	}
+
+	if params.Cursor != "" {
+		cursor, err := models.DecodeCursor(params.Cursor)
+		if err != nil {
+			return nil, invalid("invalid_cursor", err)
+		}
+		filter.After = cursor
+	} else if params.Page > 1 {
+		filter.Offset = (params.Page - 1) * filter.Limit
+	}
+
+	var err error
+	if filter.From, err = parseDateParam(params.From, false, location); err != nil {
+		return nil, ErrInvalidDateRange
+	}
+	if filter.To, err = parseDateParam(params.To, true, location); err != nil {
+		return nil, ErrInvalidDateRange
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, ErrInvalidDateRange
+	}
+
+	return filter, nil
+}
+
+// parseDateParam accepts either a date (2006-01-02), read in loc, or an
+// RFC 3339 timestamp. A plain date used as an upper bound covers the whole
+// day, so the returned time is the start of the following day.
+func parseDateParam(value string, upper bool, loc *time.Location) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.ParseInLocation(time.DateOnly, value, loc)
+	if err != nil {
+		return nil, err
+	}
+	if upper {
+		t = t.AddDate(0, 0, 1)
+	}
+
+	return &t, nil
+}
+
+func (s *saleService) SearchSales(ctx context.Context, params *models.SaleSearchParams) ([]*models.SaleSearchResult, error) {
+	q := strings.TrimSpace(params.Q)
+	if strings.IndexFunc(q, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) < 0 {
+		return nil, ErrEmptySearchQuery
+	}
+
+	limit := params.Limit
+	if limit <= 0 {
+		limit = models.DefaultPageLimit
+	}
+
+	results, err := s.repo.Search(ctx, q, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if results == nil {
+		results = []*models.SaleSearchResult{}
+	}
+
+	return results, nil
 }
 
-func (s *saleService) UpdateSales(id string, req *models.UpdateSaleRequest) (*models.Sale, error) {
+// ExportSales streams every sale in the requested date range to fn, oldest
+// first, with transaction dates in the business time zone. The range is
+// validated before the first row is read.
+func (s *saleService) ExportSales(ctx context.Context, params *models.SaleExportParams, fn func(*models.SaleExportRow) error) error {
+	filter, err := newSaleFilter(&models.SaleListParams{From: params.From, To: params.To, Order: "asc"}, s.location)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.Stream(ctx, filter, func(row *models.SaleExportRow) error {
+		row.TransactionDate = row.TransactionDate.In(s.location)
+		return fn(row)
+	})
+}
+
+func (s *saleService) UpdateSales(ctx context.Context, actor *models.User, id string, req *models.UpdateSaleRequest) (*models.Sale, error) {
 	uid, err := uuid.Parse(id)
 	if err != nil {
-		return nil, errors.New("invalid UUID format")
+		return nil, ErrInvalidID
+	}
+
+	if err := s.authorizeChange(ctx, actor, uid); err != nil {
+		return nil, err
+	}
+
+	if req.AmountReceived.Null || req.IsDebt.Null {
+		return nil, ErrNullSaleField
+	}
+
+	if req.AmountReceived.Value < 0 {
+		return nil, ErrNegativeAmount
 	}
 
-	if req.Quantity > 0 && req.Price > 0 && req.AmountReceived != 0 {
-		total := float64(req.Quantity) * req.Price
-		if req.AmountReceived < total {
-			return nil, errors.New("amount received is less than total price")
+	if req.CustomerID.Set && !req.CustomerID.Null {
+		customer, err := s.customer(ctx, req.CustomerID.Value)
+		if err != nil {
+			return nil, err
 		}
+		req.Name = models.Some(customer.Name)
 	}
 
-	sale, err := s.repo.Update(uid, req)
+	if err := s.resolveItems(ctx, req.Items); err != nil {
+		return nil, err
+	}
+
+	var sale *models.Sale
+	err = s.tx.WithTx(ctx, func(ctx context.Context) error {
+		before, err := s.repo.Lock(ctx, uid)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return ErrSaleNotFound
+		}
+
+		if err := s.checkUpdate(ctx, before, req); err != nil {
+			return err
+		}
+
+		sale, err = s.repo.Update(ctx, uid, req, &actor.ID)
+		return err
+	})
+	if errors.Is(err, repository.ErrVersionMismatch) {
+		return nil, ErrSaleModified
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	if sale == nil {
-		return nil, errors.New("sale not found")
+		return nil, ErrSaleNotFound
 	}
 
 	return sale, nil
 }
 
-func (s *saleService) DeleteSales(id string) error {
+// checkUpdate applies the rules of prepareSale to the sale as it will be once
+// req is applied to before: a sale that is not a debt must be paid in full, and
+// debt it adds must fit under the customer's credit limit.
+func (s *saleService) checkUpdate(ctx context.Context, before *models.Sale, req *models.UpdateSaleRequest) error {
+	total := before.Total
+	if req.Items != nil {
+		var err error
+		if total, err = models.ItemsTotal(req.Items); err != nil {
+			return ErrTotalTooLarge
+		}
+	}
+	amountReceived := before.AmountReceived
+	if req.AmountReceived.Set {
+		amountReceived = req.AmountReceived.Value
+	}
+	isDebt := before.IsDebt
+	if req.IsDebt.Set {
+		isDebt = req.IsDebt.Value
+	}
+	customerID := before.CustomerID
+	if req.CustomerID.Set {
+		customerID = nil
+		if !req.CustomerID.Null {
+			customerID = &req.CustomerID.Value
+		}
+	}
+
+	if !isDebt {
+		if amountReceived < total {
+			return ErrInsufficientAmount
+		}
+		return nil
+	}
+
+	if customerID == nil {
+		return nil
+	}
+
+	// The customer's balance already counts what this sale owed them before.
+	newDebt := unpaid(total, amountReceived, isDebt)
+	if before.CustomerID != nil && *before.CustomerID == *customerID {
+		newDebt -= unpaid(before.Total, before.AmountReceived, before.IsDebt)
+	}
+
+	customer, err := s.customer(ctx, *customerID)
+	if err != nil {
+		return err
+	}
+
+	return s.checkCreditLimit(ctx, customer, newDebt)
+}
+
+// resolveItems fills the name, and the price when omitted, of every item that
+// references a catalog product.
+func (s *saleService) resolveItems(ctx context.Context, items []models.SaleItemRequest) error {
+	for i := range items {
+		item := &items[i]
+		if item.ProductID == nil {
+			continue
+		}
+
+		product, err := s.activeProduct(ctx, *item.ProductID)
+		if err != nil {
+			return err
+		}
+		item.Product = product.Name
+		if item.Price == 0 {
+			item.Price = product.Price
+		}
+	}
+
+	return nil
+}
+
+// activeProduct loads a catalog product referenced by a sale.
+func (s *saleService) activeProduct(ctx context.Context, id uuid.UUID) (*models.Product, error) {
+	product, err := s.productRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if product == nil {
+		return nil, invalidReference(ErrProductNotFound)
+	}
+
+	if !product.IsActive {
+		return nil, ErrProductInactive
+	}
+
+	return product, nil
+}
+
+// customer loads the registered customer a sale is linked to.
+func (s *saleService) customer(ctx context.Context, id uuid.UUID) (*models.Customer, error) {
+	customer, err := s.customerRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if customer == nil {
+		return nil, invalidReference(ErrCustomerNotFound)
+	}
+
+	return customer, nil
+}
+
+// checkCreditLimit rejects new debt that would push a customer's outstanding
+// balance over their credit limit. A limit of zero means no limit.
+func (s *saleService) checkCreditLimit(ctx context.Context, customer *models.Customer, newDebt models.Money) error {
+	if customer.CreditLimit <= 0 || newDebt <= 0 {
+		return nil
+	}
+
+	// Sales for the same customer wait here for each other when run in a
+	// transaction, so they cannot both fit under the limit.
+	if err := s.customerRepo.Lock(ctx, customer.ID); err != nil {
+		return err
+	}
+
+	balance, err := s.debtRepo.GetCustomerBalance(ctx, customer.ID)
+	if err != nil {
+		return err
+	}
+
+	if balance.Outstanding+newDebt > customer.CreditLimit {
+		return ErrCreditLimitExceeded
+	}
+
+	return nil
+}
+
+func (s *saleService) DeleteSales(ctx context.Context, actor *models.User, id string) error {
 	uid, err := uuid.Parse(id)
 	if err != nil {
-		return errors.New("invalid UUID format")
+		return ErrInvalidID
+	}
+
+	if err := s.authorizeChange(ctx, actor, uid); err != nil {
+		return err
 	}
 
-	err = s.repo.Delete(uid)
+	err = s.repo.Delete(ctx, uid, &actor.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrSaleNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RestoreSale takes a sale out of the trash. Only users who can manage sales
+// may restore one.
+func (s *saleService) RestoreSale(ctx context.Context, actor *models.User, id string) (*models.Sale, error) {
+	uid, err := uuid.Parse(id)
 	if err != nil {
-		return errors.New("sale not found")
+		return nil, ErrInvalidID
+	}
+
+	if actor == nil || !actor.Can(models.PermissionManageSales) {
+		return nil, ErrForbidden
+	}
+
+	sale, err := s.repo.Restore(ctx, uid, &actor.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if sale == nil {
+		return nil, ErrSaleNotFound
+	}
+
+	return sale, nil
+}
+
+// PurgeTrash permanently removes sales deleted more than retention ago and
+// returns how many were removed.
+func (s *saleService) PurgeTrash(ctx context.Context, retention time.Duration) (int64, error) {
+	return s.repo.Purge(ctx, time.Now().Add(-retention))
+}
+
+// GetSaleHistory returns the recorded changes of a sale, oldest first. The
+// history of a deleted sale can still be read.
+func (s *saleService) GetSaleHistory(ctx context.Context, id string) ([]*models.AuditEntry, error) {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+
+	entries, err := s.repo.History(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		// Sales recorded before the audit log existed have no entries.
+		sale, err := s.repo.GetByID(ctx, uid)
+		if err != nil {
+			return nil, err
+		}
+
+		if sale == nil {
+			return nil, ErrSaleNotFound
+		}
+
+		entries = []*models.AuditEntry{}
+	}
+
+	return entries, nil
+}
+
+// authorizeChange checks that actor may edit or delete the sale. Users who can
+// manage sales may change any of them; users who can only record sales may
+// change sales dated today in the business time zone.
+func (s *saleService) authorizeChange(ctx context.Context, actor *models.User, id uuid.UUID) error {
+	if actor == nil || !actor.Can(models.PermissionRecordSales) {
+		return ErrForbidden
+	}
+
+	if actor.Can(models.PermissionManageSales) {
+		return nil
+	}
+
+	sale, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if sale == nil {
+		return ErrSaleNotFound
+	}
+
+	now := time.Now().In(s.location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.location)
+	if sale.TransactionDate.Before(today) {
+		return ErrForbidden
 	}
 
 	return nil
@@ -1,7 +1,10 @@
 package service
 
 import (
+	"context"
+	"database/sql"
 	"errors"
+	"math"
 	"pencatatan/internal/models"
 	"pencatatan/internal/repository"
 	"testing"
@@ -10,17 +13,20 @@ import (
 	"github.com/google/uuid"
 )
 
+func newTestSaleService(repo repository.SaleRepository) SaleService {
+	return NewSaleService(repo, &repository.MockProductRepository{}, &repository.MockCustomerRepository{}, &repository.MockDebtRepository{}, &repository.MockTxManager{}, &MockSaleRecorder{}, time.UTC)
+}
+
 func TestCreateSale_Success(t *testing.T) {
 	mockRepo := &repository.MockSaleRepository{
-		CreateFunc: func(req *models.CreateSalesRequest) (*models.Sale, error) {
+		CreateFunc: func(ctx context.Context, req *models.CreateSalesRequest) (*models.Sale, error) {
+			total, _ := models.ItemsTotal(req.Items)
 			return &models.Sale{
 				ID:             uuid.New(),
-				Product:        req.Product,
-				Quantity:       req.Quantity,
-				Price:          req.Price,
-				Total:          float64(req.Quantity) * req.Price,
+				Items:          []*models.SaleItem{{Product: req.Items[0].Product, Quantity: req.Items[0].Quantity, Price: req.Items[0].Price}},
+				Total:          total,
 				AmountReceived: req.AmountReceived,
-				ChangeAmount:   req.AmountReceived - (float64(req.Quantity) * req.Price),
+				ChangeAmount:   req.AmountReceived - total,
 				IsDebt:         req.IsDebt,
 				CreatedAt:      time.Now(),
 				UpdatedAt:      time.Now(),
@@ -28,17 +34,17 @@ func TestCreateSale_Success(t *testing.T) {
 		},
 	}
 
-	service := NewSaleService(mockRepo)
+	service := newTestSaleService(mockRepo)
 
 	req := &models.CreateSalesRequest{
-		Product:        "Test Product",
-		Quantity:       2,
-		Price:          10000,
-		AmountReceived: 25000,
+		Items: []models.SaleItemRequest{
+			{Product: "Test Product", Quantity: 2, Price: models.NewMoney(10000)},
+		},
+		AmountReceived: models.NewMoney(25000),
 		IsDebt:         false,
 	}
 
-	sale, err := service.CreateSale(req)
+	sale, err := service.CreateSale(context.Background(), req)
 
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
@@ -48,24 +54,84 @@ func TestCreateSale_Success(t *testing.T) {
 		t.Error("Expected sale to be created, got nil")
 	}
 
-	if sale.Product != req.Product {
-		t.Errorf("Expected product %s, got %s", req.Product, sale.Product)
+	if sale.Items[0].Product != req.Items[0].Product {
+		t.Errorf("Expected product %s, got %s", req.Items[0].Product, sale.Items[0].Product)
+	}
+
+	if sale.Total != models.NewMoney(20000) {
+		t.Errorf("Expected total 20000.00, got %s", sale.Total)
+	}
+}
+
+func TestCreateSale_MultipleItems(t *testing.T) {
+	var got *models.CreateSalesRequest
+	mockRepo := &repository.MockSaleRepository{
+		CreateFunc: func(ctx context.Context, req *models.CreateSalesRequest) (*models.Sale, error) {
+			got = req
+			total, _ := models.ItemsTotal(req.Items)
+			return &models.Sale{ID: uuid.New(), Total: total}, nil
+		},
+	}
+
+	service := newTestSaleService(mockRepo)
+
+	req := &models.CreateSalesRequest{
+		Items: []models.SaleItemRequest{
+			{Product: "Beras", Quantity: 2, Price: models.NewMoney(12000)},
+			{Product: "Gula", Quantity: 1, Price: models.NewMoney(15000)},
+			{Product: "Telur", Quantity: 10, Price: models.NewMoney(2000)},
+		},
+		AmountReceived: models.NewMoney(60000),
+	}
+
+	sale, err := service.CreateSale(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(got.Items) != 3 {
+		t.Errorf("Expected all 3 items passed to the repository, got %d", len(got.Items))
+	}
+
+	if sale.Total != models.NewMoney(59000) {
+		t.Errorf("Expected total 59000.00, got %s", sale.Total)
+	}
+
+	req.AmountReceived = models.NewMoney(50000)
+	if _, err := service.CreateSale(context.Background(), req); !errors.Is(err, ErrInsufficientAmount) {
+		t.Errorf("Expected insufficient amount against the receipt total, got %v", err)
+	}
+}
+
+func TestCreateSale_TotalTooLarge(t *testing.T) {
+	mockRepo := &repository.MockSaleRepository{}
+	service := newTestSaleService(mockRepo)
+
+	req := &models.CreateSalesRequest{
+		Items: []models.SaleItemRequest{
+			{Product: "Test Product", Quantity: math.MaxInt32, Price: models.Money(9999999999)},
+		},
+		IsDebt: true,
+	}
+
+	if _, err := service.CreateSale(context.Background(), req); !errors.Is(err, ErrTotalTooLarge) {
+		t.Errorf("Expected total too large error, got %v", err)
 	}
 }
 
 func TestCreateSale_InsufficientAmount(t *testing.T) {
 	mockRepo := &repository.MockSaleRepository{}
-	service := NewSaleService(mockRepo)
+	service := newTestSaleService(mockRepo)
 
 	req := &models.CreateSalesRequest{
-		Product:        "Test Product",
-		Quantity:       2,
-		Price:          10000,
-		AmountReceived: 15000, // Less than total (20000)
+		Items: []models.SaleItemRequest{
+			{Product: "Test Product", Quantity: 2, Price: models.NewMoney(10000)},
+		},
+		AmountReceived: models.NewMoney(15000), // Less than total (20000)
 		IsDebt:         false,
 	}
 
-	sale, err := service.CreateSale(req)
+	sale, err := service.CreateSale(context.Background(), req)
 
 	if err == nil {
 		t.Error("Expected error for insufficient amount, got nil")
@@ -81,25 +147,239 @@ func TestCreateSale_InsufficientAmount(t *testing.T) {
 	}
 }
 
+func TestCreateSale_FromCatalogProduct(t *testing.T) {
+	productID := uuid.New()
+	mockProductRepo := &repository.MockProductRepository{
+		GetByIDFunc: func(ctx context.Context, id uuid.UUID) (*models.Product, error) {
+			return &models.Product{ID: id, Name: "Kopi Susu", Price: models.NewMoney(8000), IsActive: true}, nil
+		},
+	}
+	mockRepo := &repository.MockSaleRepository{
+		CreateFunc: func(ctx context.Context, req *models.CreateSalesRequest) (*models.Sale, error) {
+			item := req.Items[0]
+			return &models.Sale{ID: uuid.New(), Items: []*models.SaleItem{{Product: item.Product, ProductID: item.ProductID, Price: item.Price}}}, nil
+		},
+	}
+
+	service := NewSaleService(mockRepo, mockProductRepo, &repository.MockCustomerRepository{}, &repository.MockDebtRepository{}, &repository.MockTxManager{}, &MockSaleRecorder{}, time.UTC)
+
+	req := &models.CreateSalesRequest{
+		Items: []models.SaleItemRequest{
+			{ProductID: &productID, Product: "kopi ", Quantity: 2},
+		},
+		AmountReceived: models.NewMoney(20000),
+	}
+
+	sale, err := service.CreateSale(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if sale.Items[0].Product != "Kopi Susu" {
+		t.Errorf("Expected catalog product name, got %s", sale.Items[0].Product)
+	}
+
+	if sale.Items[0].Price != models.NewMoney(8000) {
+		t.Errorf("Expected default price 8000.00, got %s", sale.Items[0].Price)
+	}
+}
+
+func TestCreateSale_InactiveProduct(t *testing.T) {
+	productID := uuid.New()
+	mockProductRepo := &repository.MockProductRepository{
+		GetByIDFunc: func(ctx context.Context, id uuid.UUID) (*models.Product, error) {
+			return &models.Product{ID: id, Name: "Teh", Price: models.NewMoney(5000), IsActive: false}, nil
+		},
+	}
+
+	service := NewSaleService(&repository.MockSaleRepository{}, mockProductRepo, &repository.MockCustomerRepository{}, &repository.MockDebtRepository{}, &repository.MockTxManager{}, &MockSaleRecorder{}, time.UTC)
+
+	_, err := service.CreateSale(context.Background(), &models.CreateSalesRequest{
+		Items: []models.SaleItemRequest{{ProductID: &productID, Quantity: 1}},
+	})
+
+	if !errors.Is(err, ErrProductInactive) {
+		t.Errorf("Expected inactive product error, got %v", err)
+	}
+}
+
+func TestCreateSale_ForCustomer(t *testing.T) {
+	customerID := uuid.New()
+	mockCustomerRepo := &repository.MockCustomerRepository{
+		GetByIDFunc: func(ctx context.Context, id uuid.UUID) (*models.Customer, error) {
+			return &models.Customer{ID: id, Name: "Bu Sari"}, nil
+		},
+	}
+	mockRepo := &repository.MockSaleRepository{
+		CreateFunc: func(ctx context.Context, req *models.CreateSalesRequest) (*models.Sale, error) {
+			return &models.Sale{ID: uuid.New(), Name: req.Name, CustomerID: req.CustomerID}, nil
+		},
+	}
+
+	service := NewSaleService(mockRepo, &repository.MockProductRepository{}, mockCustomerRepo, &repository.MockDebtRepository{}, &repository.MockTxManager{}, &MockSaleRecorder{}, time.UTC)
+
+	sale, err := service.CreateSale(context.Background(), &models.CreateSalesRequest{
+		CustomerID: &customerID,
+		Name:       "sari",
+		Items:      []models.SaleItemRequest{{Product: "Beras", Quantity: 1, Price: models.NewMoney(12000)}},
+		IsDebt:     true,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if sale.Name != "Bu Sari" || *sale.CustomerID != customerID {
+		t.Errorf("Expected sale linked to customer, got %q %v", sale.Name, sale.CustomerID)
+	}
+}
+
+func TestCreateSale_CreditLimitExceeded(t *testing.T) {
+	customerID := uuid.New()
+	mockCustomerRepo := &repository.MockCustomerRepository{
+		GetByIDFunc: func(ctx context.Context, id uuid.UUID) (*models.Customer, error) {
+			return &models.Customer{ID: id, Name: "Bu Sari", CreditLimit: models.NewMoney(100000)}, nil
+		},
+	}
+	mockDebtRepo := &repository.MockDebtRepository{
+		GetCustomerBalanceFunc: func(ctx context.Context, id uuid.UUID) (*models.CustomerBalance, error) {
+			return &models.CustomerBalance{CustomerID: id, Outstanding: models.NewMoney(90000)}, nil
+		},
+	}
+
+	service := NewSaleService(&repository.MockSaleRepository{}, &repository.MockProductRepository{}, mockCustomerRepo, mockDebtRepo, &repository.MockTxManager{}, &MockSaleRecorder{}, time.UTC)
+
+	_, err := service.CreateSale(context.Background(), &models.CreateSalesRequest{
+		CustomerID: &customerID,
+		Items:      []models.SaleItemRequest{{Product: "Beras", Quantity: 1, Price: models.NewMoney(15000)}},
+		IsDebt:     true,
+	})
+
+	if !errors.Is(err, ErrCreditLimitExceeded) {
+		t.Errorf("Expected credit limit error, got %v", err)
+	}
+}
+
+func TestCreateSale_RunsInTransaction(t *testing.T) {
+	type txKey struct{}
+	customerID := uuid.New()
+	var locked, created bool
+	mockCustomerRepo := &repository.MockCustomerRepository{
+		GetByIDFunc: func(ctx context.Context, id uuid.UUID) (*models.Customer, error) {
+			return &models.Customer{ID: id, Name: "Bu Sari", CreditLimit: models.NewMoney(100000)}, nil
+		},
+		LockFunc: func(ctx context.Context, id uuid.UUID) error {
+			locked = ctx.Value(txKey{}) != nil
+			return nil
+		},
+	}
+	mockDebtRepo := &repository.MockDebtRepository{
+		GetCustomerBalanceFunc: func(ctx context.Context, id uuid.UUID) (*models.CustomerBalance, error) {
+			return &models.CustomerBalance{CustomerID: id}, nil
+		},
+	}
+	mockRepo := &repository.MockSaleRepository{
+		CreateFunc: func(ctx context.Context, req *models.CreateSalesRequest) (*models.Sale, error) {
+			created = ctx.Value(txKey{}) != nil
+			return nil, errors.New("insert failed")
+		},
+	}
+	mockTx := &repository.MockTxManager{
+		WithTxFunc: func(ctx context.Context, fn func(ctx context.Context) error) error {
+			return fn(context.WithValue(ctx, txKey{}, true))
+		},
+	}
+
+	service := NewSaleService(mockRepo, &repository.MockProductRepository{}, mockCustomerRepo, mockDebtRepo, mockTx, &MockSaleRecorder{}, time.UTC)
+
+	_, err := service.CreateSale(context.Background(), &models.CreateSalesRequest{
+		CustomerID: &customerID,
+		Items:      []models.SaleItemRequest{{Product: "Beras", Quantity: 1, Price: models.NewMoney(15000)}},
+		IsDebt:     true,
+	})
+	if err == nil {
+		t.Fatal("Expected the insert error")
+	}
+
+	if !locked || !created {
+		t.Errorf("Expected the customer lock and the insert in the transaction, got lock %t insert %t", locked, created)
+	}
+}
+
+func TestCreateSale_RecordsSale(t *testing.T) {
+	var source, currency string
+	var total, debt models.Money
+	recorder := &MockSaleRecorder{
+		SaleRecordedFunc: func(s, c string, t, d models.Money) {
+			source, currency, total, debt = s, c, t, d
+		},
+	}
+	mockRepo := &repository.MockSaleRepository{
+		CreateFunc: func(ctx context.Context, req *models.CreateSalesRequest) (*models.Sale, error) {
+			total, _ := models.ItemsTotal(req.Items)
+			return &models.Sale{
+				ID:             uuid.New(),
+				Total:          total,
+				AmountReceived: req.AmountReceived,
+				IsDebt:         req.IsDebt,
+				Currency:       req.Currency,
+			}, nil
+		},
+	}
+
+	service := NewSaleService(mockRepo, &repository.MockProductRepository{}, &repository.MockCustomerRepository{}, &repository.MockDebtRepository{}, &repository.MockTxManager{}, recorder, time.UTC)
+
+	_, err := service.CreateSale(context.Background(), &models.CreateSalesRequest{
+		Items:          []models.SaleItemRequest{{Product: "Beras", Quantity: 2, Price: models.NewMoney(15000)}},
+		AmountReceived: models.NewMoney(10000),
+		IsDebt:         true,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if source != SaleSourceAPI || currency != models.DefaultCurrency || total != models.NewMoney(30000) || debt != models.NewMoney(20000) {
+		t.Errorf("Expected the sale to be recorded, got %s %s %v %v", source, currency, total, debt)
+	}
+}
+
+func TestCreateSale_UnknownCustomer(t *testing.T) {
+	customerID := uuid.New()
+	service := newTestSaleService(&repository.MockSaleRepository{})
+
+	_, err := service.CreateSale(context.Background(), &models.CreateSalesRequest{
+		CustomerID: &customerID,
+		Items:      []models.SaleItemRequest{{Product: "Beras", Quantity: 1, Price: 100}},
+	})
+
+	if !errors.Is(err, ErrCustomerNotFound) {
+		t.Errorf("Expected customer not found error, got %v", err)
+	}
+
+	// The customer is part of the request body, so this is a validation
+	// error rather than a missing sale.
+	if KindOf(err) != KindValidation {
+		t.Errorf("Expected a validation error, got kind %d", KindOf(err))
+	}
+}
+
 func TestGetSaleByID_Success(t *testing.T) {
 	expectedID := uuid.New()
 	mockRepo := &repository.MockSaleRepository{
-		GetByIDFunc: func(id uuid.UUID) (*models.Sale, error) {
+		GetByIDFunc: func(ctx context.Context, id uuid.UUID) (*models.Sale, error) {
 			if id == expectedID {
 				return &models.Sale{
-					ID:       expectedID,
-					Product:  "Test Product",
-					Quantity: 1,
-					Price:    5000,
+					ID:    expectedID,
+					Items: []*models.SaleItem{{Product: "Test Product", Quantity: 1, Price: models.NewMoney(5000)}},
+					Total: models.NewMoney(5000),
 				}, nil
 			}
 			return nil, nil
 		},
 	}
 
-	service := NewSaleService(mockRepo)
+	service := newTestSaleService(mockRepo)
 
-	sale, err := service.GetSaleByID(expectedID.String())
+	sale, err := service.GetSaleByID(context.Background(), expectedID.String())
 
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
@@ -116,9 +396,9 @@ func TestGetSaleByID_Success(t *testing.T) {
 
 func TestGetSaleByID_InvalidUUID(t *testing.T) {
 	mockRepo := &repository.MockSaleRepository{}
-	service := NewSaleService(mockRepo)
+	service := newTestSaleService(mockRepo)
 
-	sale, err := service.GetSaleByID("invalid-uuid")
+	sale, err := service.GetSaleByID(context.Background(), "invalid-uuid")
 
 	if err == nil {
 		t.Error("Expected error for invalid UUID, got nil")
@@ -136,14 +416,14 @@ func TestGetSaleByID_InvalidUUID(t *testing.T) {
 
 func TestGetSaleByID_NotFound(t *testing.T) {
 	mockRepo := &repository.MockSaleRepository{
-		GetByIDFunc: func(id uuid.UUID) (*models.Sale, error) {
+		GetByIDFunc: func(ctx context.Context, id uuid.UUID) (*models.Sale, error) {
 			return nil, nil // Not found
 		},
 	}
 
-	service := NewSaleService(mockRepo)
+	service := newTestSaleService(mockRepo)
 
-	sale, err := service.GetSaleByID(uuid.New().String())
+	sale, err := service.GetSaleByID(context.Background(), uuid.New().String())
 
 	if err == nil {
 		t.Error("Expected error for not found sale, got nil")
@@ -161,51 +441,201 @@ func TestGetSaleByID_NotFound(t *testing.T) {
 
 func TestGetAllSales_Success(t *testing.T) {
 	expectedSales := []*models.Sale{
-		{ID: uuid.New(), Product: "Product 1"},
-		{ID: uuid.New(), Product: "Product 2"},
+		{ID: uuid.New(), Total: models.NewMoney(1000)},
+		{ID: uuid.New(), Total: models.NewMoney(2000)},
 	}
 
 	mockRepo := &repository.MockSaleRepository{
-		GetAllFunc: func() ([]*models.Sale, error) {
-			return expectedSales, nil
+		GetAllFunc: func(ctx context.Context, filter *models.SaleFilter) ([]*models.Sale, int64, error) {
+			return expectedSales, int64(len(expectedSales)), nil
 		},
 	}
 
-	service := NewSaleService(mockRepo)
+	service := newTestSaleService(mockRepo)
 
-	sales, err := service.GetAllSales()
+	page, err := service.GetAllSales(context.Background(), &models.SaleListParams{})
 
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
 
-	if len(sales) != len(expectedSales) {
-		t.Errorf("Expected %d sales, got %d", len(expectedSales), len(sales))
+	if len(page.Sales) != len(expectedSales) {
+		t.Errorf("Expected %d sales, got %d", len(expectedSales), len(page.Sales))
+	}
+
+	if page.Meta.HasMore {
+		t.Error("Expected no further page")
+	}
+}
+
+func TestGetAllSales_Pagination(t *testing.T) {
+	var gotFilter *models.SaleFilter
+	mockRepo := &repository.MockSaleRepository{
+		GetAllFunc: func(ctx context.Context, filter *models.SaleFilter) ([]*models.Sale, int64, error) {
+			gotFilter = filter
+			sales := make([]*models.Sale, filter.Limit)
+			for i := range sales {
+				sales[i] = &models.Sale{ID: uuid.New(), Total: models.NewMoney(int64(i + 1))}
+			}
+			return sales, 25, nil
+		},
+	}
+
+	service := newTestSaleService(mockRepo)
+
+	isDebt := true
+	page, err := service.GetAllSales(context.Background(), &models.SaleListParams{
+		Page:   2,
+		Limit:  10,
+		IsDebt: &isDebt,
+		From:   "2026-01-01",
+		To:     "2026-01-31",
+		Sort:   "total",
+		Order:  "asc",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if gotFilter.Offset != 10 {
+		t.Errorf("Expected offset 10, got %d", gotFilter.Offset)
+	}
+	if gotFilter.Desc {
+		t.Error("Expected ascending order")
+	}
+	if gotFilter.To.Day() != 1 || gotFilter.To.Month() != time.February {
+		t.Errorf("Expected to to be exclusive end of day, got %v", gotFilter.To)
+	}
+
+	if len(page.Sales) != 10 {
+		t.Errorf("Expected 10 sales, got %d", len(page.Sales))
+	}
+	if page.Meta.Page != 2 || page.Meta.TotalPages != 3 || page.Meta.Total != 25 {
+		t.Errorf("Unexpected meta %+v", page.Meta)
+	}
+	if !page.Meta.HasMore || page.Meta.NextCursor == "" {
+		t.Fatal("Expected a next cursor")
+	}
+
+	cursor, err := models.DecodeCursor(page.Meta.NextCursor)
+	if err != nil {
+		t.Fatalf("Expected cursor to decode, got %v", err)
+	}
+	if cursor.Value != "10.00" || cursor.ID != page.Sales[9].ID {
+		t.Errorf("Expected cursor at last row, got %+v", cursor)
+	}
+}
+
+func TestGetAllSales_InvalidParams(t *testing.T) {
+	service := newTestSaleService(&repository.MockSaleRepository{})
+
+	if _, err := service.GetAllSales(context.Background(), &models.SaleListParams{Cursor: "not-a-cursor"}); !errors.Is(err, models.ErrInvalidCursor) {
+		t.Errorf("Expected invalid cursor error, got %v", err)
+	}
+
+	cursor := models.Cursor{Value: "10.00", ID: uuid.New(), Sort: "total", Desc: true}.Encode()
+	if _, err := service.GetAllSales(context.Background(), &models.SaleListParams{Cursor: cursor}); !errors.Is(err, models.ErrInvalidCursor) || KindOf(err) != KindValidation {
+		t.Errorf("Expected a cursor from another sort order to be rejected, got %v", err)
+	}
+
+	if _, err := service.GetAllSales(context.Background(), &models.SaleListParams{From: "2026-02-01", To: "2026-01-01"}); !errors.Is(err, ErrInvalidDateRange) {
+		t.Errorf("Expected invalid date range error, got %v", err)
+	}
+}
+
+func TestSearchSales_EmptyQuery(t *testing.T) {
+	service := newTestSaleService(&repository.MockSaleRepository{})
+
+	_, err := service.SearchSales(context.Background(), &models.SaleSearchParams{Q: " %& "})
+
+	if !errors.Is(err, ErrEmptySearchQuery) {
+		t.Errorf("Expected empty query error, got %v", err)
+	}
+}
+
+func TestExportSales_BusinessTimezone(t *testing.T) {
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+
+	var gotFilter *models.SaleFilter
+	mockRepo := &repository.MockSaleRepository{
+		StreamFunc: func(ctx context.Context, filter *models.SaleFilter, fn func(*models.SaleExportRow) error) error {
+			gotFilter = filter
+			return fn(&models.SaleExportRow{Sale: models.Sale{TransactionDate: time.Date(2026, 10, 1, 20, 0, 0, 0, time.UTC)}})
+		},
+	}
+
+	service := NewSaleService(mockRepo, &repository.MockProductRepository{}, &repository.MockCustomerRepository{}, &repository.MockDebtRepository{}, &repository.MockTxManager{}, &MockSaleRecorder{}, jakarta)
+
+	var rows []*models.SaleExportRow
+	err = service.ExportSales(context.Background(), &models.SaleExportParams{From: "2026-10-01", To: "2026-10-31"}, func(row *models.SaleExportRow) error {
+		rows = append(rows, row)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !gotFilter.From.Equal(time.Date(2026, 10, 1, 0, 0, 0, 0, jakarta)) || gotFilter.Desc {
+		t.Errorf("Unexpected filter %+v", gotFilter)
+	}
+
+	if len(rows) != 1 || rows[0].TransactionDate.Day() != 2 || rows[0].TransactionDate.Hour() != 3 {
+		t.Errorf("Expected transaction date in business time zone, got %v", rows[0].TransactionDate)
+	}
+}
+
+func TestExportSales_InvalidRange(t *testing.T) {
+	called := false
+	mockRepo := &repository.MockSaleRepository{
+		StreamFunc: func(ctx context.Context, filter *models.SaleFilter, fn func(*models.SaleExportRow) error) error {
+			called = true
+			return nil
+		},
+	}
+
+	service := newTestSaleService(mockRepo)
+
+	err := service.ExportSales(context.Background(), &models.SaleExportParams{From: "2026-10-31", To: "2026-10-01"}, func(*models.SaleExportRow) error { return nil })
+
+	if !errors.Is(err, ErrInvalidDateRange) || called {
+		t.Errorf("Expected invalid date range before streaming, got %v", err)
+	}
+}
+
+// lockedSale returns a LockFunc that finds sale under any id.
+func lockedSale(sale models.Sale) func(ctx context.Context, id uuid.UUID) (*models.Sale, error) {
+	return func(ctx context.Context, id uuid.UUID) (*models.Sale, error) {
+		sale.ID = id
+		return &sale, nil
 	}
 }
 
 func TestUpdateSales_Success(t *testing.T) {
 	expectedID := uuid.New()
 	mockRepo := &repository.MockSaleRepository{
-		UpdateFunc: func(id uuid.UUID, req *models.UpdateSaleRequest) (*models.Sale, error) {
+		LockFunc: lockedSale(models.Sale{Total: models.NewMoney(75000), AmountReceived: models.NewMoney(100000)}),
+		UpdateFunc: func(ctx context.Context, id uuid.UUID, req *models.UpdateSaleRequest, actorID *uuid.UUID) (*models.Sale, error) {
+			total, _ := models.ItemsTotal(req.Items)
 			return &models.Sale{
-				ID:       id,
-				Product:  req.Product,
-				Quantity: req.Quantity,
-				Price:    req.Price,
+				ID:    id,
+				Items: []*models.SaleItem{{Product: req.Items[0].Product, Quantity: req.Items[0].Quantity, Price: req.Items[0].Price}},
+				Total: total,
 			}, nil
 		},
 	}
 
-	service := NewSaleService(mockRepo)
+	service := newTestSaleService(mockRepo)
 
 	req := &models.UpdateSaleRequest{
-		Product:  "Updated Product",
-		Quantity: 5,
-		Price:    15000,
+		Items: []models.SaleItemRequest{
+			{Product: "Updated Product", Quantity: 5, Price: models.NewMoney(15000)},
+		},
 	}
 
-	sale, err := service.UpdateSales(expectedID.String(), req)
+	sale, err := service.UpdateSales(context.Background(), testOwner, expectedID.String(), req)
 
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
@@ -215,20 +645,20 @@ func TestUpdateSales_Success(t *testing.T) {
 		t.Error("Expected updated sale, got nil")
 	}
 
-	if sale.Product != req.Product {
-		t.Errorf("Expected product %s, got %s", req.Product, sale.Product)
+	if sale.Items[0].Product != req.Items[0].Product {
+		t.Errorf("Expected product %s, got %s", req.Items[0].Product, sale.Items[0].Product)
 	}
 }
 
 func TestUpdateSales_InvalidUUID(t *testing.T) {
 	mockRepo := &repository.MockSaleRepository{}
-	service := NewSaleService(mockRepo)
+	service := newTestSaleService(mockRepo)
 
 	req := &models.UpdateSaleRequest{
-		Product: "Updated Product",
+		Name: models.Some("Updated Customer"),
 	}
 
-	sale, err := service.UpdateSales("invalid-uuid", req)
+	sale, err := service.UpdateSales(context.Background(), testOwner, "invalid-uuid", req)
 
 	if err == nil {
 		t.Error("Expected error for invalid UUID, got nil")
@@ -245,16 +675,17 @@ func TestUpdateSales_InvalidUUID(t *testing.T) {
 }
 
 func TestUpdateSales_InsufficientAmount(t *testing.T) {
-	mockRepo := &repository.MockSaleRepository{}
-	service := NewSaleService(mockRepo)
+	mockRepo := &repository.MockSaleRepository{LockFunc: lockedSale(models.Sale{})}
+	service := newTestSaleService(mockRepo)
 
 	req := &models.UpdateSaleRequest{
-		Quantity:       2,
-		Price:          10000,
-		AmountReceived: 15000, // Less than total (20000)
+		Items: []models.SaleItemRequest{
+			{Product: "Test Product", Quantity: 2, Price: models.NewMoney(10000)},
+		},
+		AmountReceived: models.Some(models.NewMoney(15000)), // Less than total (20000)
 	}
 
-	sale, err := service.UpdateSales(uuid.New().String(), req)
+	sale, err := service.UpdateSales(context.Background(), testOwner, uuid.New().String(), req)
 
 	if err == nil {
 		t.Error("Expected error for insufficient amount, got nil")
@@ -267,14 +698,14 @@ func TestUpdateSales_InsufficientAmount(t *testing.T) {
 
 func TestDeleteSales_Success(t *testing.T) {
 	mockRepo := &repository.MockSaleRepository{
-		DeleteFunc: func(id uuid.UUID) error {
+		DeleteFunc: func(ctx context.Context, id uuid.UUID, actorID *uuid.UUID) error {
 			return nil
 		},
 	}
 
-	service := NewSaleService(mockRepo)
+	service := newTestSaleService(mockRepo)
 
-	err := service.DeleteSales(uuid.New().String())
+	err := service.DeleteSales(context.Background(), testOwner, uuid.New().String())
 
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
@@ -283,9 +714,9 @@ func TestDeleteSales_Success(t *testing.T) {
 
 func TestDeleteSales_InvalidUUID(t *testing.T) {
 	mockRepo := &repository.MockSaleRepository{}
-	service := NewSaleService(mockRepo)
+	service := newTestSaleService(mockRepo)
 
-	err := service.DeleteSales("invalid-uuid")
+	err := service.DeleteSales(context.Background(), testOwner, "invalid-uuid")
 
 	if err == nil {
 		t.Error("Expected error for invalid UUID, got nil")
@@ -299,16 +730,340 @@ func TestDeleteSales_InvalidUUID(t *testing.T) {
 
 func TestDeleteSales_NotFound(t *testing.T) {
 	mockRepo := &repository.MockSaleRepository{
-		DeleteFunc: func(id uuid.UUID) error {
-			return errors.New("sale not found")
+		DeleteFunc: func(ctx context.Context, id uuid.UUID, actorID *uuid.UUID) error {
+			return sql.ErrNoRows
 		},
 	}
 
-	service := NewSaleService(mockRepo)
+	service := newTestSaleService(mockRepo)
 
-	err := service.DeleteSales(uuid.New().String())
+	err := service.DeleteSales(context.Background(), testOwner, uuid.New().String())
 
-	if err == nil {
-		t.Error("Expected error for not found sale, got nil")
+	if !errors.Is(err, ErrSaleNotFound) {
+		t.Errorf("Expected sale not found error, got %v", err)
+	}
+}
+
+func TestDeleteSales_DatabaseError(t *testing.T) {
+	mockRepo := &repository.MockSaleRepository{
+		DeleteFunc: func(ctx context.Context, id uuid.UUID, actorID *uuid.UUID) error {
+			return errors.New("connection reset")
+		},
+	}
+
+	service := newTestSaleService(mockRepo)
+
+	err := service.DeleteSales(context.Background(), testOwner, uuid.New().String())
+
+	if err == nil || KindOf(err) != KindInternal {
+		t.Errorf("Expected the database error to be passed through, got %v", err)
+	}
+}
+
+var testOwner = &models.User{ID: uuid.New(), Username: "pemilik", Role: models.RoleOwner, IsActive: true}
+
+func TestDeleteSales_CashierLimitedToToday(t *testing.T) {
+	saleDate := time.Now()
+	mockRepo := &repository.MockSaleRepository{
+		GetByIDFunc: func(ctx context.Context, id uuid.UUID) (*models.Sale, error) {
+			return &models.Sale{ID: id, TransactionDate: saleDate}, nil
+		},
+		DeleteFunc: func(ctx context.Context, id uuid.UUID, actorID *uuid.UUID) error {
+			return nil
+		},
+	}
+	service := newTestSaleService(mockRepo)
+	cashier := &models.User{ID: uuid.New(), Role: models.RoleCashier, IsActive: true}
+
+	if err := service.DeleteSales(context.Background(), cashier, uuid.New().String()); err != nil {
+		t.Errorf("Expected cashier to delete today's sale, got %v", err)
+	}
+
+	saleDate = time.Now().AddDate(0, 0, -1)
+	if err := service.DeleteSales(context.Background(), cashier, uuid.New().String()); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected forbidden for a past-day sale, got %v", err)
+	}
+
+	if err := service.DeleteSales(context.Background(), testOwner, uuid.New().String()); err != nil {
+		t.Errorf("Expected owner to delete a past-day sale, got %v", err)
+	}
+}
+
+func TestUpdateSales_Forbidden(t *testing.T) {
+	mockRepo := &repository.MockSaleRepository{
+		GetByIDFunc: func(ctx context.Context, id uuid.UUID) (*models.Sale, error) {
+			return &models.Sale{ID: id, TransactionDate: time.Now().AddDate(0, -1, 0)}, nil
+		},
+		UpdateFunc: func(ctx context.Context, id uuid.UUID, req *models.UpdateSaleRequest, actorID *uuid.UUID) (*models.Sale, error) {
+			t.Error("Expected the sale not to be updated")
+			return nil, nil
+		},
+	}
+	service := newTestSaleService(mockRepo)
+
+	actors := []*models.User{
+		nil,
+		{ID: uuid.New(), Role: models.RoleViewer},
+		{ID: uuid.New(), Role: models.RoleCashier},
+	}
+	for _, actor := range actors {
+		if _, err := service.UpdateSales(context.Background(), actor, uuid.New().String(), &models.UpdateSaleRequest{Name: models.Some("Bu Sri")}); !errors.Is(err, ErrForbidden) {
+			t.Errorf("Expected forbidden for %v, got %v", actor, err)
+		}
+	}
+}
+
+func TestUpdateSales_PassesActor(t *testing.T) {
+	var gotActor *uuid.UUID
+	mockRepo := &repository.MockSaleRepository{
+		LockFunc: lockedSale(models.Sale{}),
+		UpdateFunc: func(ctx context.Context, id uuid.UUID, req *models.UpdateSaleRequest, actorID *uuid.UUID) (*models.Sale, error) {
+			gotActor = actorID
+			return &models.Sale{ID: id}, nil
+		},
+	}
+	service := newTestSaleService(mockRepo)
+
+	if _, err := service.UpdateSales(context.Background(), testOwner, uuid.New().String(), &models.UpdateSaleRequest{Name: models.Some("Bu Sri")}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if gotActor == nil || *gotActor != testOwner.ID {
+		t.Errorf("Expected actor %s to be recorded, got %v", testOwner.ID, gotActor)
+	}
+}
+
+func TestUpdateSales_VersionMismatch(t *testing.T) {
+	mockRepo := &repository.MockSaleRepository{
+		LockFunc: lockedSale(models.Sale{}),
+		UpdateFunc: func(ctx context.Context, id uuid.UUID, req *models.UpdateSaleRequest, actorID *uuid.UUID) (*models.Sale, error) {
+			return nil, repository.ErrVersionMismatch
+		},
+	}
+	service := newTestSaleService(mockRepo)
+
+	_, err := service.UpdateSales(context.Background(), testOwner, uuid.New().String(), &models.UpdateSaleRequest{Name: models.Some("Bu Sri"), Version: 1})
+	if !errors.Is(err, ErrSaleModified) {
+		t.Errorf("Expected sale modified error, got %v", err)
+	}
+}
+
+func TestUpdateSales_NullAmount(t *testing.T) {
+	service := newTestSaleService(&repository.MockSaleRepository{})
+
+	req := &models.UpdateSaleRequest{AmountReceived: models.Optional[models.Money]{Set: true, Null: true}}
+	if _, err := service.UpdateSales(context.Background(), testOwner, uuid.New().String(), req); !errors.Is(err, ErrNullSaleField) {
+		t.Errorf("Expected null field error, got %v", err)
+	}
+}
+
+func TestUpdateSales_DetachCustomer(t *testing.T) {
+	var got *models.UpdateSaleRequest
+	mockRepo := &repository.MockSaleRepository{
+		LockFunc: lockedSale(models.Sale{}),
+		UpdateFunc: func(ctx context.Context, id uuid.UUID, req *models.UpdateSaleRequest, actorID *uuid.UUID) (*models.Sale, error) {
+			got = req
+			return &models.Sale{ID: id}, nil
+		},
+	}
+	customerRepo := &repository.MockCustomerRepository{
+		GetByIDFunc: func(ctx context.Context, id uuid.UUID) (*models.Customer, error) {
+			t.Error("Expected no customer lookup when detaching")
+			return nil, nil
+		},
+	}
+	service := NewSaleService(mockRepo, &repository.MockProductRepository{}, customerRepo, &repository.MockDebtRepository{}, &repository.MockTxManager{}, &MockSaleRecorder{}, time.UTC)
+
+	req := &models.UpdateSaleRequest{
+		CustomerID: models.Optional[uuid.UUID]{Set: true, Null: true},
+		IsDebt:     models.Some(false),
+	}
+	if _, err := service.UpdateSales(context.Background(), testOwner, uuid.New().String(), req); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if got.Name.Set || !got.CustomerID.Null || !got.IsDebt.Set {
+		t.Errorf("Expected only customer_id and is_debt to change, got %+v", got)
+	}
+}
+
+func TestUpdateSales_ChecksMergedSale(t *testing.T) {
+	tests := []struct {
+		name   string
+		before models.Sale
+		req    *models.UpdateSaleRequest
+	}{
+		{
+			name:   "amount only",
+			before: models.Sale{Total: models.NewMoney(20000), AmountReceived: models.NewMoney(20000)},
+			req:    &models.UpdateSaleRequest{AmountReceived: models.Some(models.Money(0))},
+		},
+		{
+			name:   "debt settled without payment",
+			before: models.Sale{Total: models.NewMoney(20000), AmountReceived: models.NewMoney(5000), IsDebt: true},
+			req:    &models.UpdateSaleRequest{IsDebt: models.Some(false)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &repository.MockSaleRepository{
+				LockFunc: lockedSale(tt.before),
+				UpdateFunc: func(ctx context.Context, id uuid.UUID, req *models.UpdateSaleRequest, actorID *uuid.UUID) (*models.Sale, error) {
+					t.Error("Expected the sale not to be updated")
+					return nil, nil
+				},
+			}
+			service := newTestSaleService(mockRepo)
+
+			if _, err := service.UpdateSales(context.Background(), testOwner, uuid.New().String(), tt.req); !errors.Is(err, ErrInsufficientAmount) {
+				t.Errorf("Expected insufficient amount error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestUpdateSales_ItemsOnDebtSale(t *testing.T) {
+	mockRepo := &repository.MockSaleRepository{
+		LockFunc: lockedSale(models.Sale{Total: models.NewMoney(10000), IsDebt: true}),
+		UpdateFunc: func(ctx context.Context, id uuid.UUID, req *models.UpdateSaleRequest, actorID *uuid.UUID) (*models.Sale, error) {
+			total, _ := models.ItemsTotal(req.Items)
+			return &models.Sale{ID: id, Total: total, AmountReceived: req.AmountReceived.Value, IsDebt: true}, nil
+		},
+	}
+	service := newTestSaleService(mockRepo)
+
+	req := &models.UpdateSaleRequest{
+		Items:          []models.SaleItemRequest{{Product: "Beras", Quantity: 2, Price: models.NewMoney(10000)}},
+		AmountReceived: models.Some(models.NewMoney(5000)),
+	}
+	if _, err := service.UpdateSales(context.Background(), testOwner, uuid.New().String(), req); err != nil {
+		t.Errorf("Expected a partly paid debt sale to be accepted, got %v", err)
+	}
+}
+
+func TestUpdateSales_BecomesDebtOverCreditLimit(t *testing.T) {
+	customerID := uuid.New()
+	mockRepo := &repository.MockSaleRepository{
+		LockFunc: lockedSale(models.Sale{CustomerID: &customerID, Total: models.NewMoney(50000), AmountReceived: models.NewMoney(50000)}),
+		UpdateFunc: func(ctx context.Context, id uuid.UUID, req *models.UpdateSaleRequest, actorID *uuid.UUID) (*models.Sale, error) {
+			t.Error("Expected the sale not to be updated")
+			return nil, nil
+		},
+	}
+	customerRepo := &repository.MockCustomerRepository{
+		GetByIDFunc: func(ctx context.Context, id uuid.UUID) (*models.Customer, error) {
+			return &models.Customer{ID: id, Name: "Bu Sari", CreditLimit: models.NewMoney(30000)}, nil
+		},
+	}
+	service := NewSaleService(mockRepo, &repository.MockProductRepository{}, customerRepo, &repository.MockDebtRepository{}, &repository.MockTxManager{}, &MockSaleRecorder{}, time.UTC)
+
+	req := &models.UpdateSaleRequest{IsDebt: models.Some(true), AmountReceived: models.Some(models.Money(0))}
+	if _, err := service.UpdateSales(context.Background(), testOwner, uuid.New().String(), req); !errors.Is(err, ErrCreditLimitExceeded) {
+		t.Errorf("Expected credit limit exceeded error, got %v", err)
+	}
+}
+
+func TestGetSaleHistory(t *testing.T) {
+	deletedID := uuid.New()
+	legacyID := uuid.New()
+	mockRepo := &repository.MockSaleRepository{
+		HistoryFunc: func(ctx context.Context, id uuid.UUID) ([]*models.AuditEntry, error) {
+			if id == deletedID {
+				return []*models.AuditEntry{{EntityID: id, Action: models.AuditActionDelete}}, nil
+			}
+			return nil, nil
+		},
+		GetByIDFunc: func(ctx context.Context, id uuid.UUID) (*models.Sale, error) {
+			if id == legacyID {
+				return &models.Sale{ID: id}, nil
+			}
+			return nil, nil
+		},
+	}
+	service := newTestSaleService(mockRepo)
+
+	entries, err := service.GetSaleHistory(context.Background(), deletedID.String())
+	if err != nil || len(entries) != 1 {
+		t.Errorf("Expected the history of a deleted sale, got %v, %v", entries, err)
+	}
+
+	entries, err = service.GetSaleHistory(context.Background(), legacyID.String())
+	if err != nil || entries == nil || len(entries) != 0 {
+		t.Errorf("Expected an empty history for a sale without entries, got %v, %v", entries, err)
+	}
+
+	if _, err := service.GetSaleHistory(context.Background(), uuid.New().String()); !errors.Is(err, ErrSaleNotFound) {
+		t.Errorf("Expected sale not found, got %v", err)
+	}
+}
+
+func TestGetTrash_ListsDeletedSales(t *testing.T) {
+	var got *models.SaleFilter
+	mockRepo := &repository.MockSaleRepository{
+		GetAllFunc: func(ctx context.Context, filter *models.SaleFilter) ([]*models.Sale, int64, error) {
+			got = filter
+			return nil, 0, nil
+		},
+	}
+	service := newTestSaleService(mockRepo)
+
+	page, err := service.GetTrash(context.Background(), &models.SaleListParams{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !got.Deleted || got.Sort != "deleted_at" || !got.Desc {
+		t.Errorf("Expected deleted sales, newest deletion first, got %+v", got)
+	}
+	if page.Sales == nil {
+		t.Error("Expected an empty list, got nil")
+	}
+}
+
+func TestRestoreSale(t *testing.T) {
+	restoredID := uuid.New()
+	mockRepo := &repository.MockSaleRepository{
+		RestoreFunc: func(ctx context.Context, id uuid.UUID, actorID *uuid.UUID) (*models.Sale, error) {
+			if id != restoredID {
+				return nil, nil
+			}
+			return &models.Sale{ID: id}, nil
+		},
+	}
+	service := newTestSaleService(mockRepo)
+
+	if sale, err := service.RestoreSale(context.Background(), testOwner, restoredID.String()); err != nil || sale.ID != restoredID {
+		t.Errorf("Expected sale to be restored, got %v, %v", sale, err)
+	}
+
+	if _, err := service.RestoreSale(context.Background(), testOwner, uuid.New().String()); !errors.Is(err, ErrSaleNotFound) {
+		t.Errorf("Expected sale not found for a sale outside the trash, got %v", err)
+	}
+
+	cashier := &models.User{ID: uuid.New(), Role: models.RoleCashier}
+	if _, err := service.RestoreSale(context.Background(), cashier, restoredID.String()); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected forbidden for a cashier, got %v", err)
+	}
+}
+
+func TestPurgeTrash_UsesRetention(t *testing.T) {
+	var cutoff time.Time
+	mockRepo := &repository.MockSaleRepository{
+		PurgeFunc: func(ctx context.Context, deletedBefore time.Time) (int64, error) {
+			cutoff = deletedBefore
+			return 3, nil
+		},
+	}
+	service := newTestSaleService(mockRepo)
+
+	purged, err := service.PurgeTrash(context.Background(), 30*24*time.Hour)
+	if err != nil || purged != 3 {
+		t.Fatalf("Expected 3 purged sales, got %d, %v", purged, err)
+	}
+
+	expected := time.Now().AddDate(0, 0, -30)
+	if cutoff.Sub(expected).Abs() > time.Minute {
+		t.Errorf("Expected cutoff near %v, got %v", expected, cutoff)
 	}
 }
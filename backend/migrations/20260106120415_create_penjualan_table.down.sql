@@ -1 +1 @@
-DROP TABLE IF EXISTS sales;
\ No newline at end of file
+DROP TABLE IF EXISTS sales;
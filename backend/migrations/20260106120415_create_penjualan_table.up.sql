@@ -14,4 +14,4 @@ CREATE TABLE IF NOT EXISTS sales (
 );
 
 CREATE INDEX IF NOT EXISTS idx_sales_transaction_date ON sales(transaction_date);
-CREATE INDEX IF NOT EXISTS idx_sales_created_at ON sales(created_at);
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS idx_sales_created_at ON sales(created_at);
@@ -0,0 +1 @@
+ALTER TABLE sales ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'IDR';
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_sales_product_trgm;
+DROP INDEX IF EXISTS idx_sales_name_trgm;
+DROP INDEX IF EXISTS idx_sales_search_vector;
+ALTER TABLE sales DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,11 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE sales ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', coalesce(name, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce(product, '')), 'B')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_sales_search_vector ON sales USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_sales_name_trgm ON sales USING GIN (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_sales_product_trgm ON sales USING GIN (product gin_trgm_ops);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_sales_product_id;
+ALTER TABLE sales DROP COLUMN IF EXISTS product_id;
+DROP TABLE IF EXISTS products;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS products (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    sku VARCHAR(64) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    unit VARCHAR(32) NOT NULL DEFAULT 'pcs',
+    price NUMERIC(10, 2) NOT NULL CHECK (price >= 0),
+    cost_price NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (cost_price >= 0),
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_sku ON products (lower(sku));
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_name ON products (lower(name));
+
+ALTER TABLE sales ADD COLUMN IF NOT EXISTS product_id UUID NULL REFERENCES products(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_sales_product_id ON sales(product_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_sales_customer_id;
+ALTER TABLE sales DROP COLUMN IF EXISTS customer_id;
+DROP TABLE IF EXISTS customers;
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS customers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    phone VARCHAR(32) NULL,
+    address TEXT NULL,
+    notes TEXT NULL,
+    credit_limit NUMERIC(12, 2) NOT NULL DEFAULT 0 CHECK (credit_limit >= 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_customers_name ON customers (lower(name));
+
+ALTER TABLE sales ADD COLUMN IF NOT EXISTS customer_id UUID NULL REFERENCES customers(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_sales_customer_id ON sales(customer_id);
+
+-- Backfill one customer per distinct buyer name, ignoring case and
+-- surrounding spaces, then link the existing sales to them.
+INSERT INTO customers (name)
+SELECT DISTINCT ON (lower(trim(name))) trim(name)
+FROM sales
+WHERE name IS NOT NULL AND trim(name) <> ''
+ORDER BY lower(trim(name)), created_at;
+
+UPDATE sales s
+SET customer_id = c.id
+FROM customers c
+WHERE s.customer_id IS NULL
+  AND lower(trim(s.name)) = lower(c.name);
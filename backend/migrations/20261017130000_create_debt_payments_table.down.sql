@@ -0,0 +1,3 @@
+DROP VIEW IF EXISTS sale_debts;
+DROP INDEX IF EXISTS idx_sales_is_debt;
+DROP TABLE IF EXISTS debt_payments;
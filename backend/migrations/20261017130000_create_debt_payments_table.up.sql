@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS debt_payments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    sale_id UUID NOT NULL REFERENCES sales(id) ON DELETE CASCADE,
+    customer_id UUID NULL REFERENCES customers(id) ON DELETE SET NULL,
+    amount NUMERIC(12, 2) NOT NULL CHECK (amount > 0),
+    paid_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    note TEXT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_debt_payments_sale_id ON debt_payments(sale_id);
+CREATE INDEX IF NOT EXISTS idx_debt_payments_customer_id ON debt_payments(customer_id);
+CREATE INDEX IF NOT EXISTS idx_sales_is_debt ON sales(is_debt) WHERE is_debt;
+
+-- sale_debts is the single definition of how much is still owed on a sale:
+-- the unpaid part at checkout minus every repayment recorded since.
+CREATE OR REPLACE VIEW sale_debts AS
+SELECT s.id AS sale_id,
+       s.customer_id,
+       COALESCE(s.name, '') AS name,
+       s.transaction_date,
+       s.total,
+       s.amount_received,
+       GREATEST(s.total - s.amount_received, 0) AS debt_amount,
+       COALESCE(p.paid, 0) AS paid_amount,
+       GREATEST(s.total - s.amount_received, 0) - COALESCE(p.paid, 0) AS outstanding
+FROM sales s
+LEFT JOIN (
+    SELECT sale_id, SUM(amount) AS paid
+    FROM debt_payments
+    GROUP BY sale_id
+) p ON p.sale_id = s.id;
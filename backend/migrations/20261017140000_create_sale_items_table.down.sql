@@ -0,0 +1,70 @@
+-- Receipts with several lines cannot be split back into separate sales, so
+-- they are folded into one line whose price is the receipt total.
+DROP VIEW IF EXISTS sale_debts;
+DROP INDEX IF EXISTS idx_sales_search_vector;
+
+ALTER TABLE sales
+    DROP COLUMN IF EXISTS search_vector,
+    DROP COLUMN IF EXISTS change_amount,
+    ADD COLUMN IF NOT EXISTS product TEXT NULL,
+    ADD COLUMN IF NOT EXISTS product_id UUID NULL REFERENCES products(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS quantity INTEGER NULL,
+    ADD COLUMN IF NOT EXISTS price NUMERIC(10, 2) NULL;
+
+UPDATE sales s
+SET product = i.product,
+    product_id = i.product_id,
+    quantity = i.quantity,
+    price = i.price
+FROM (
+    SELECT sale_id,
+           string_agg(product, ', ' ORDER BY position) AS product,
+           CASE WHEN COUNT(*) = 1 THEN MIN(product_id::text)::uuid END AS product_id,
+           CASE WHEN COUNT(*) = 1 THEN MIN(quantity) ELSE 1 END AS quantity,
+           CASE WHEN COUNT(*) = 1 THEN MIN(price) ELSE SUM(subtotal) END AS price
+    FROM sale_items
+    GROUP BY sale_id
+) i
+WHERE i.sale_id = s.id;
+
+UPDATE sales SET product = '', quantity = 1, price = 0 WHERE product IS NULL;
+
+ALTER TABLE sales
+    ALTER COLUMN product TYPE VARCHAR(255) USING left(product, 255),
+    ALTER COLUMN product SET NOT NULL,
+    ALTER COLUMN quantity SET NOT NULL,
+    ALTER COLUMN price SET NOT NULL,
+    ADD CONSTRAINT sales_quantity_check CHECK (quantity > 0),
+    ADD CONSTRAINT sales_price_check CHECK (price >= 0),
+    DROP COLUMN IF EXISTS total;
+
+ALTER TABLE sales
+    ADD COLUMN total NUMERIC(12, 2) GENERATED ALWAYS AS (quantity * price) STORED,
+    ADD COLUMN change_amount NUMERIC(12, 2) GENERATED ALWAYS AS (amount_received - (quantity * price)) STORED,
+    ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', coalesce(name, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce(product, '')), 'B')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_sales_product_id ON sales(product_id);
+CREATE INDEX IF NOT EXISTS idx_sales_search_vector ON sales USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_sales_product_trgm ON sales USING GIN (product gin_trgm_ops);
+
+DROP TABLE IF EXISTS sale_items;
+
+CREATE OR REPLACE VIEW sale_debts AS
+SELECT s.id AS sale_id,
+       s.customer_id,
+       COALESCE(s.name, '') AS name,
+       s.transaction_date,
+       s.total,
+       s.amount_received,
+       GREATEST(s.total - s.amount_received, 0) AS debt_amount,
+       COALESCE(p.paid, 0) AS paid_amount,
+       GREATEST(s.total - s.amount_received, 0) - COALESCE(p.paid, 0) AS outstanding
+FROM sales s
+LEFT JOIN (
+    SELECT sale_id, SUM(amount) AS paid
+    FROM debt_payments
+    GROUP BY sale_id
+) p ON p.sale_id = s.id;
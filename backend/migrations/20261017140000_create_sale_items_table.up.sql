@@ -0,0 +1,74 @@
+CREATE TABLE IF NOT EXISTS sale_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    sale_id UUID NOT NULL REFERENCES sales(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL DEFAULT 0,
+    product_id UUID NULL REFERENCES products(id) ON DELETE SET NULL,
+    product VARCHAR(255) NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    price NUMERIC(10, 2) NOT NULL CHECK (price >= 0),
+    subtotal NUMERIC(12, 2) GENERATED ALWAYS AS (quantity * price) STORED,
+    search_vector tsvector GENERATED ALWAYS AS (to_tsvector('simple', product)) STORED,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_sale_items_sale_id ON sale_items(sale_id, position);
+CREATE INDEX IF NOT EXISTS idx_sale_items_product_id ON sale_items(product_id);
+CREATE INDEX IF NOT EXISTS idx_sale_items_search_vector ON sale_items USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_sale_items_product_trgm ON sale_items USING GIN (product gin_trgm_ops);
+
+-- Every existing sale becomes a receipt with a single line.
+INSERT INTO sale_items (sale_id, product_id, product, quantity, price)
+SELECT id, product_id, product, quantity, price
+FROM sales;
+
+-- The view and the search vector depend on the columns that move to
+-- sale_items, so both are rebuilt around the new header.
+DROP VIEW IF EXISTS sale_debts;
+DROP INDEX IF EXISTS idx_sales_product_trgm;
+DROP INDEX IF EXISTS idx_sales_search_vector;
+DROP INDEX IF EXISTS idx_sales_product_id;
+
+ALTER TABLE sales
+    DROP COLUMN IF EXISTS search_vector,
+    DROP COLUMN IF EXISTS change_amount,
+    DROP COLUMN IF EXISTS total,
+    DROP COLUMN IF EXISTS product,
+    DROP COLUMN IF EXISTS product_id,
+    DROP COLUMN IF EXISTS quantity,
+    DROP COLUMN IF EXISTS price;
+
+ALTER TABLE sales ADD COLUMN IF NOT EXISTS total NUMERIC(12, 2) NOT NULL DEFAULT 0 CHECK (total >= 0);
+
+UPDATE sales s
+SET total = i.total
+FROM (
+    SELECT sale_id, SUM(subtotal) AS total
+    FROM sale_items
+    GROUP BY sale_id
+) i
+WHERE i.sale_id = s.id;
+
+ALTER TABLE sales ADD COLUMN IF NOT EXISTS change_amount NUMERIC(12, 2)
+    GENERATED ALWAYS AS (amount_received - total) STORED;
+
+ALTER TABLE sales ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (setweight(to_tsvector('simple', coalesce(name, '')), 'A')) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_sales_search_vector ON sales USING GIN (search_vector);
+
+CREATE OR REPLACE VIEW sale_debts AS
+SELECT s.id AS sale_id,
+       s.customer_id,
+       COALESCE(s.name, '') AS name,
+       s.transaction_date,
+       s.total,
+       s.amount_received,
+       GREATEST(s.total - s.amount_received, 0) AS debt_amount,
+       COALESCE(p.paid, 0) AS paid_amount,
+       GREATEST(s.total - s.amount_received, 0) - COALESCE(p.paid, 0) AS outstanding
+FROM sales s
+LEFT JOIN (
+    SELECT sale_id, SUM(amount) AS paid
+    FROM debt_payments
+    GROUP BY sale_id
+) p ON p.sale_id = s.id;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_sales_created_by;
+ALTER TABLE sales DROP COLUMN IF EXISTS created_by;
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS users;
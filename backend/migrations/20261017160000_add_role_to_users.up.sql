@@ -0,0 +1,6 @@
+-- Users created before roles existed were set up by whoever runs the shop,
+-- so they become owners. New users default to cashier.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(16) NOT NULL DEFAULT 'owner'
+    CHECK (role IN ('owner', 'cashier', 'viewer'));
+
+ALTER TABLE users ALTER COLUMN role SET DEFAULT 'cashier';
@@ -0,0 +1,25 @@
+-- Sales still in the trash are removed, as a delete did before.
+DELETE FROM sales WHERE deleted_at IS NOT NULL;
+
+CREATE OR REPLACE VIEW sale_debts AS
+SELECT s.id AS sale_id,
+       s.customer_id,
+       COALESCE(s.name, '') AS name,
+       s.transaction_date,
+       s.total,
+       s.amount_received,
+       GREATEST(s.total - s.amount_received, 0) AS debt_amount,
+       COALESCE(p.paid, 0) AS paid_amount,
+       GREATEST(s.total - s.amount_received, 0) - COALESCE(p.paid, 0) AS outstanding
+FROM sales s
+LEFT JOIN (
+    SELECT sale_id, SUM(amount) AS paid
+    FROM debt_payments
+    GROUP BY sale_id
+) p ON p.sale_id = s.id;
+
+DROP INDEX IF EXISTS idx_sales_deleted_at;
+ALTER TABLE sales DROP COLUMN IF EXISTS deleted_at;
+
+-- The audit_log action check is left as is: it may already hold restore and
+-- purge entries, and the log cannot be changed.
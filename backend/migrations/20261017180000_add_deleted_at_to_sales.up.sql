@@ -0,0 +1,28 @@
+ALTER TABLE sales ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE NULL;
+
+CREATE INDEX IF NOT EXISTS idx_sales_deleted_at ON sales(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Sales in the trash no longer count as debts.
+CREATE OR REPLACE VIEW sale_debts AS
+SELECT s.id AS sale_id,
+       s.customer_id,
+       COALESCE(s.name, '') AS name,
+       s.transaction_date,
+       s.total,
+       s.amount_received,
+       GREATEST(s.total - s.amount_received, 0) AS debt_amount,
+       COALESCE(p.paid, 0) AS paid_amount,
+       GREATEST(s.total - s.amount_received, 0) - COALESCE(p.paid, 0) AS outstanding
+FROM sales s
+LEFT JOIN (
+    SELECT sale_id, SUM(amount) AS paid
+    FROM debt_payments
+    GROUP BY sale_id
+) p ON p.sale_id = s.id
+WHERE s.deleted_at IS NULL;
+
+-- A delete now moves the sale to the trash; restore takes it back out and
+-- purge removes it for good once the retention period has passed.
+ALTER TABLE audit_log DROP CONSTRAINT IF EXISTS audit_log_action_check;
+ALTER TABLE audit_log ADD CONSTRAINT audit_log_action_check
+    CHECK (action IN ('create', 'update', 'delete', 'restore', 'purge'));
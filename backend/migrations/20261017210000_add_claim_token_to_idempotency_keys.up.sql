@@ -0,0 +1,3 @@
+-- claim_token identifies the request holding a key, so only that request can
+-- store its response, renew the claim or release it.
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS claim_token UUID NOT NULL DEFAULT gen_random_uuid();
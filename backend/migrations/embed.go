@@ -0,0 +1,9 @@
+package migrations
+
+import "embed"
+
+// FS holds every *.sql migration so the binary can migrate the database
+// without shipping the migrations directory alongside it.
+//
+//go:embed *.sql
+var FS embed.FS